@@ -0,0 +1,155 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	vote "github.com/k33nice/vote-bot/pkg"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// commandTarget - user the command is about: sender of the replied message
+// or the first argument looked up among players of chat, the rest of arguments
+// are returned as well.
+func commandTarget(m *tb.Message, chat *tb.Chat) (*tb.User, []string, error) {
+	args := strings.Fields(m.Payload)
+
+	if m.ReplyTo != nil && m.ReplyTo.Sender != nil {
+		return m.ReplyTo.Sender, args, nil
+	}
+
+	query := ""
+	if len(args) > 0 {
+		query, args = args[0], args[1:]
+	}
+
+	user, err := bot.FindUser(chat.ID, query)
+	if err != nil {
+		return nil, args, err
+	}
+
+	return user, args, nil
+}
+
+// canManage - report whether sender may grant or revoke `role` in chat,
+// only owners manage roles equal to or higher than their own.
+func canManage(chat *tb.Chat, sender *tb.User, role string) bool {
+	own := bot.RoleOf(chat, sender)
+	if own == vote.RoleOwner {
+		return true
+	}
+
+	return vote.RoleRank(role) < vote.RoleRank(own)
+}
+
+func handleAdminAdd(m *tb.Message) {
+	if !checkAdmin(m) {
+		return
+	}
+
+	chat := roleChat(m)
+	if chat == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	user, args, err := commandTarget(m, chat)
+	if err != nil {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "roles.add_usage", strings.Join(vote.GrantableRoles, "|"), err))
+		return
+	}
+
+	role := vote.RoleAdmin
+	if len(args) > 0 {
+		role = strings.ToLower(args[0])
+	}
+
+	if !canManage(chat, m.Sender, role) || !canManage(chat, m.Sender, bot.RoleOf(chat, user)) {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "permission_denied"))
+		return
+	}
+
+	if err := bot.GrantRole(chat, user, role); err != nil {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "roles.add_usage", strings.Join(vote.GrantableRoles, "|"), err))
+		return
+	}
+
+	bot.Send(m.Chat, bot.T(m.Chat.ID, "roles.added", displayUser(user), bot.T(m.Chat.ID, "role."+role)))
+}
+
+func handleAdminRemove(m *tb.Message) {
+	if !checkAdmin(m) {
+		return
+	}
+
+	chat := roleChat(m)
+	if chat == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	user, _, err := commandTarget(m, chat)
+	if err != nil {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "roles.remove_usage", err))
+		return
+	}
+
+	if !canManage(chat, m.Sender, bot.RoleOf(chat, user)) {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "permission_denied"))
+		return
+	}
+
+	if err := bot.RevokeRole(chat, user); err != nil {
+		log.Printf("cannot revoke role: %s", err)
+		return
+	}
+
+	bot.Send(m.Chat, bot.T(m.Chat.ID, "roles.removed", displayUser(user)))
+}
+
+func handleAdmins(m *tb.Message) {
+	chat := roleChat(m)
+	if chat == nil {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	roles, err := bot.Store.GetRoles(chat.ID)
+	if err != nil {
+		log.Printf("cannot get roles: %s", err)
+		return
+	}
+
+	cfg := bot.Config()
+	lines := []string{bot.T(m.Chat.ID, "roles.title")}
+	for _, id := range cfg.Owners {
+		lines = append(lines, bot.T(m.Chat.ID, "roles.line", strconv.Itoa(id), bot.T(m.Chat.ID, "role."+vote.RoleOwner)))
+	}
+	for _, id := range cfg.Admins {
+		lines = append(lines, bot.T(m.Chat.ID, "roles.line", strconv.Itoa(id), bot.T(m.Chat.ID, "role."+vote.RoleAdmin)))
+	}
+
+	sort.Slice(roles, func(i, j int) bool { return vote.RoleRank(roles[i].Role) > vote.RoleRank(roles[j].Role) })
+	for _, r := range roles {
+		lines = append(lines, bot.T(m.Chat.ID, "roles.line", r.Name, bot.T(m.Chat.ID, "role."+r.Role)))
+	}
+
+	if cfg.InheritChatAdmins {
+		lines = append(lines, bot.T(m.Chat.ID, "roles.inherited"))
+	}
+
+	bot.Send(m.Chat, strings.Join(lines, "\n"))
+}
+
+func displayUser(u *tb.User) string {
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+	if u.FirstName != "" {
+		return strings.TrimSpace(u.FirstName + " " + u.LastName)
+	}
+
+	return strconv.Itoa(u.ID)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	vote "github.com/k33nice/vote-bot/pkg"
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// handleBalance - show own balance, treasurers see every non-zero balance too
+// and change balance of a player with /balance @user 500 or as a reply.
+func handleBalance(m *tb.Message) {
+	chat := roleChat(m)
+	if chat == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	if m.ReplyTo != nil || strings.TrimSpace(m.Payload) != "" {
+		if !checkRole(m, vote.RoleTreasurer) {
+			return
+		}
+		addBalance(m, chat)
+		return
+	}
+
+	balance, err := bot.Balance(chat.ID, m.Sender.ID)
+	if err != nil {
+		log.Printf("cannot get balance: %s", err)
+		return
+	}
+	lines := []string{bot.T(m.Chat.ID, "balance.own", balance)}
+
+	if bot.HasRole(chat, m.Sender, vote.RoleTreasurer) {
+		balances, err := bot.Balances(chat.ID)
+		if err != nil {
+			log.Printf("cannot get balances: %s", err)
+			return
+		}
+		for _, b := range balances {
+			lines = append(lines, bot.T(m.Chat.ID, "balance.line", b.Name, b.Balance))
+		}
+	}
+
+	bot.Send(m.Sender, strings.Join(lines, "\n"))
+}
+
+func addBalance(m *tb.Message, chat *tb.Chat) {
+	user, args, err := commandTarget(m, chat)
+	if err == nil && len(args) != 1 {
+		err = errors.New("amount is required")
+	}
+	var amount int
+	if err == nil {
+		amount, err = strconv.Atoi(strings.TrimPrefix(args[0], "+"))
+	}
+	if err != nil {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "balance.usage", err))
+		return
+	}
+
+	if err := bot.AddBalance(chat.ID, user, amount); err != nil {
+		log.Printf("cannot change balance: %s", err)
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "balance.usage", err))
+		return
+	}
+
+	balance, err := bot.Balance(chat.ID, user.ID)
+	if err != nil {
+		log.Printf("cannot get balance: %s", err)
+		return
+	}
+	bot.Send(m.Chat, bot.T(m.Chat.ID, "balance.line", displayUser(user), balance))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// handleDrive - offer seats to the game: /drive [seats], /drive off withdraws.
+func handleDrive(m *tb.Message) {
+	seats := bot.Config().Carpool.Seats
+	switch arg := strings.TrimSpace(m.Payload); arg {
+	case "":
+	case "off", "0":
+		seats = -1
+	default:
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 {
+			bot.Send(m.Chat, bot.T(m.Chat.ID, "carpool.usage", arg))
+			return
+		}
+		seats = n
+	}
+
+	setRide(m, seats)
+}
+
+// handleRide - ask for a ride to the game, /ride off withdraws.
+func handleRide(m *tb.Message) {
+	seats := 0
+	if strings.TrimSpace(m.Payload) == "off" {
+		seats = -1
+	}
+
+	setRide(m, seats)
+}
+
+func setRide(m *tb.Message, seats int) {
+	if bot.Channel == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+	if bot.Config().Carpool.Seats == 0 {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "carpool.disabled"))
+		return
+	}
+
+	player, err := bot.RegisterPlayer(bot.Channel.ID, m.Sender)
+	if err == nil {
+		err = bot.SetRide(player, seats)
+	}
+	if err != nil {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "carpool.usage", err))
+		return
+	}
+
+	bot.Send(m.Chat, bot.T(m.Chat.ID, "ok"))
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// handleDuties - how many times every player had every duty.
+func handleDuties(m *tb.Message) {
+	chat := roleChat(m)
+	if chat == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	history, err := bot.DutyHistory(chat.ID)
+	if err != nil {
+		log.Printf("cannot get duty history: %s", err)
+		return
+	}
+	if len(history) == 0 {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "duty.empty"))
+		return
+	}
+
+	lines := []string{bot.T(m.Chat.ID, "duty.history")}
+	for _, p := range history {
+		var names []string
+		for name := range p.Duties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var counts []string
+		for _, name := range names {
+			counts = append(counts, fmt.Sprintf("%s %d", name, p.Duties[name]))
+		}
+		lines = append(lines, p.Name+": "+strings.Join(counts, ", "))
+	}
+
+	bot.Send(m.Chat, strings.Join(lines, "\n"))
+}
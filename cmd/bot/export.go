@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	vote "github.com/k33nice/vote-bot/pkg"
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const exportDate = "2006-01-02"
+
+// handleExport - send file with games and votes of chat privately:
+// /export [csv|json] [from] [to], dates are inclusive and all time by default.
+func handleExport(m *tb.Message) {
+	if !checkRole(m, vote.RoleTreasurer) {
+		return
+	}
+
+	chat := roleChat(m)
+	if chat == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	format, from, to, err := exportArgs(chat, strings.Fields(m.Payload))
+	if err != nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "export.usage", strings.Join(vote.ExportFormats, "|"), err))
+		return
+	}
+
+	if err := bot.SendExport(m.Sender, chat.ID, format, from, to); err != nil {
+		log.Printf("cannot export: %s", err)
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "export.failed", err))
+	}
+}
+
+// exportArgs - parse format and inclusive date range of /export in the chat timezone.
+func exportArgs(chat *tb.Chat, args []string) (format string, from, to time.Time, err error) {
+	from, to, rest, err := periodArgs(chat, args)
+	if err != nil {
+		return "", from, to, err
+	}
+
+	format = vote.ExportCSV
+	for _, a := range rest {
+		if !vote.Contains(vote.ExportFormats, strings.ToLower(a)) {
+			return "", from, to, errors.Errorf("unknown argument %q", a)
+		}
+		format = strings.ToLower(a)
+	}
+
+	return format, from, to, nil
+}
+
+// periodArgs - parse up to two dates of arguments into [from, to) in the chat
+// timezone, the last date is inclusive and the period is all time without dates.
+// Other arguments are returned as they are.
+func periodArgs(chat *tb.Chat, args []string) (from, to time.Time, rest []string, err error) {
+	s, err := bot.Settings(chat.ID)
+	if err != nil {
+		return from, to, nil, err
+	}
+	loc := s.Location()
+
+	var dates []time.Time
+	for _, a := range args {
+		if d, err := time.ParseInLocation(exportDate, a, loc); err == nil {
+			dates = append(dates, d)
+			continue
+		}
+		rest = append(rest, a)
+	}
+
+	from, to = time.Unix(0, 0), time.Now().AddDate(1, 0, 0)
+	switch len(dates) {
+	case 0:
+	case 1:
+		from = dates[0]
+	case 2:
+		from, to = dates[0], dates[1].AddDate(0, 0, 1)
+	default:
+		return from, to, rest, errors.New("too many dates")
+	}
+
+	return from, to, rest, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	vote "github.com/k33nice/vote-bot/pkg"
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const importUsage = `usage: vote-bot import -chat <id> [-dry-run] <file.csv>
+
+CSV needs a header with date and user_id or username columns,
+name and option (yes/no) are optional.
+`
+
+// importCommand - caption of a CSV document that imports it.
+const importCommand = "/import"
+
+// maxReportProblems - skipped rows listed in the report, telegram messages are limited.
+const maxReportProblems = 50
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, importUsage)
+		fs.PrintDefaults()
+	}
+	chatID := fs.Int64("chat", 0, "id of the chat games are imported to")
+	dryRun := fs.Bool("dry-run", false, "only report rows that cannot be imported")
+	fs.Parse(args)
+
+	if *chatID == 0 || fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := vote.NewConfigLoader().Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	store, err := newStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "cannot open csv"))
+	}
+	defer f.Close()
+
+	b := vote.NewBotWith(nil, &tb.User{}, cfg, store)
+	report, err := b.ImportAttendance(f, *chatID, *dryRun)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "cannot import"))
+	}
+
+	fmt.Println(formatReport(report, func(key string, args ...interface{}) string {
+		return b.T(*chatID, key, args...)
+	}))
+}
+
+// handleDocument - import attendance from CSV document sent by admin with /import caption,
+// "/import dry" only reports what would be imported.
+func handleDocument(m *tb.Message) {
+	if !strings.HasPrefix(m.Caption, importCommand) || !checkAdmin(m) {
+		return
+	}
+
+	chat := roleChat(m)
+	if chat == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	r, err := bot.GetFile(&m.Document.File)
+	if err != nil {
+		log.Printf("cannot download import: %s", err)
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "import.failed", err))
+		return
+	}
+	defer r.Close()
+
+	dryRun := strings.Contains(strings.TrimPrefix(m.Caption, importCommand), "dry")
+	report, err := bot.ImportAttendance(r, chat.ID, dryRun)
+	if err != nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "import.failed", err))
+		return
+	}
+
+	bot.Send(m.Sender, formatReport(report, func(key string, args ...interface{}) string {
+		return bot.T(m.Chat.ID, key, args...)
+	}))
+}
+
+func formatReport(r *vote.ImportReport, t func(key string, args ...interface{}) string) string {
+	key := "import.done"
+	if r.DryRun {
+		key = "import.dry_run"
+	}
+
+	lines := []string{t(key, r.Rows, r.Games, r.Votes, len(r.Unmatched))}
+	for i, p := range r.Unmatched {
+		if i == maxReportProblems {
+			lines = append(lines, t("import.more", len(r.Unmatched)-i))
+			break
+		}
+		lines = append(lines, p.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
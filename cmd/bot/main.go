@@ -3,25 +3,37 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	vote "github.com/k33nice/vote-bot/pkg"
+	"github.com/k33nice/vote-bot/pkg/model"
 	"github.com/pkg/errors"
 	tb "gopkg.in/tucnak/telebot.v2"
 )
 
-var cfg *vote.Config
+var loader *vote.ConfigLoader
 var bot *vote.Bot
 
-func init() {
-	cfg = vote.NewConfig()
+func setup() {
+	loader = vote.NewConfigLoader()
+	cfg, err := loader.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	store, err := newStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	b, err := vote.NewBot(tb.Settings{
 		Token:  cfg.APIToken,
 		Poller: &tb.LongPoller{Timeout: 10 * time.Second},
-	}, cfg)
+	}, cfg, store)
 
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "cannot create new bot"))
@@ -29,82 +41,118 @@ func init() {
 	}
 
 	bot = b
+
+	loader.Watch(func(c *vote.Config, err error) {
+		if err != nil {
+			log.Printf("config is not reloaded: %s", err)
+			return
+		}
+
+		if err := bot.SetConfig(c); err != nil {
+			log.Printf("caught err: %s", err)
+		}
+	})
+}
+
+// newStore - return store selected by STORE env, MySQL by default.
+func newStore() (model.Store, error) {
+	if os.Getenv("STORE") == "memory" {
+		log.Println("Using in-memory store, data will be lost on exit")
+		return model.NewMemoryStore(), nil
+	}
+
+	engine, err := model.GetEngine()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := engine.Migrate(); err != nil {
+		return nil, errors.Wrap(err, "cannot migrate database")
+	}
+
+	return engine, nil
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		runImport(os.Args[2:])
+		return
+	}
+
+	setup()
+
 	bot.Handle("/help", func(m *tb.Message) {
-		bot.Send(m.Chat, "```"+vote.HelpMessage+"```", tb.ModeMarkdown)
+		bot.Send(m.Chat, "```"+bot.T(m.Chat.ID, "help")+"```", tb.ModeMarkdown)
 	})
 
 	bot.Handle("/where", func(m *tb.Message) {
-		loc := tb.Location{Lat: float32(cfg.Place.Location.Latitude), Lng: float32(cfg.Place.Location.Longitude)}
-		loc.Send(&bot.Bot, m.Chat, &tb.SendOptions{})
+		place := bot.Config().Place
+		loc := tb.Location{Lat: float32(place.Location.Latitude), Lng: float32(place.Location.Longitude)}
+		bot.Send(m.Chat, &loc)
 	})
 
 	bot.Handle("/info", func(m *tb.Message) {
-		bot.Send(m.Chat, cfg.Place.URL)
+		bot.Send(m.Chat, bot.Config().Place.URL)
 	})
 
 	bot.Handle("/result", func(m *tb.Message) {
-		bot.Send(m.Chat, bot.GetVoteResult())
+		result, err := bot.GetVoteResult()
+		if err != nil {
+			log.Printf("cannot get vote result: %s", err)
+			return
+		}
+		bot.Send(m.Chat, result)
 	})
 
 	bot.Handle("/start", handleStart)
 
 	bot.Handle("/start_on_channel", handleStartOnChannel)
 
+	bot.Handle("/lang", handleLang)
 	bot.Handle("/set_date", handleSetDate)
+	bot.Handle("/timezone", handleTimezone)
 	bot.Handle("/create", handleCreate)
+	bot.Handle("/reload", handleReload)
+	bot.Handle("/preview", handlePreview)
+	bot.Handle("/settings", handleSettings)
+	bot.Handle("/admin_add", handleAdminAdd)
+	bot.Handle("/admin_remove", handleAdminRemove)
+	bot.Handle("/admins", handleAdmins)
+	bot.Handle("/notify", handleNotify)
+	bot.Handle("/quiet", handleQuiet)
+	bot.Handle("/stop", handleStop)
+	bot.Handle("/nick", handleNick)
+	bot.Handle("/display", handleDisplay)
+	bot.Handle("/export", handleExport)
+	bot.Handle("/add", handleAdd)
+	bot.Handle("/remove", handleRemove)
+	bot.Handle("/audit", handleAudit)
+	bot.Handle("/reason", handleReason)
+	bot.Handle("/stats", handleStats)
+	bot.Handle("/balance", handleBalance)
+	bot.Handle("/drive", handleDrive)
+	bot.Handle("/ride", handleRide)
+	bot.Handle("/duties", handleDuties)
+	bot.Handle("/poll", handlePoll)
+	bot.Handle("/poll_close", handlePollClose)
 
 	bot.Handle(tb.OnAddedToGroup, handleStart)
+	bot.Handle(tb.OnDocument, handleDocument)
+	bot.Handle(tb.OnText, handleText)
+	bot.Handle(tb.OnQuery, func(q *tb.Query) {
+		if err := bot.AnswerQuery(q); err != nil {
+			log.Printf("caught err: %s", err)
+		}
+	})
 
 	go func() {
 		for {
 			log.Println("***BOT LOOP***")
-			var un string
-			var date time.Time
-
-			now := time.Now()
-			switch m := bot.Pinned.(type) {
-			case *tb.Message:
-				date = m.Time()
-				un = m.Sender.Username
-			case *vote.PinnedMessage:
-				un = m.From.Username
-				date = time.Unix(int64(m.Date), 0)
-			}
-
-			curYear, curWeek := now.ISOWeek()
-			pinYear, pinWeek := date.ISOWeek()
-			if (curWeek != pinWeek || curYear != pinYear) && now.Hour() == 16 && un == bot.Me.Username {
-				log.Println("Unpin message")
-
-				err := bot.UnpinMessage()
-				if err != nil {
-					log.Printf("cannot upin, err: %v", err)
-				}
-			}
-
-			if bot.Pinned != nil && un == bot.Me.Username && (curWeek == pinWeek && curYear == pinYear) {
-				log.Println("Update vote")
-				if err := bot.UpdateVote(); err != nil {
-					log.Printf("caught err: %s", err)
-				}
-			}
-
-			if bot.Pinned == nil {
-				log.Println("Create vote")
-				if err := bot.CreateVote(); err != nil {
-					log.Printf("caught error: %s", err)
-				}
-			}
-
-			if int(now.Weekday()) == bot.Config.Weekday-1 && now.Hour() == 20 && now.Minute() == 0 {
-				log.Println("send reminder")
-				// bot.SendReminder()
-			}
-
-			bot.CreateHandlers()
+			bot.Tick(time.Now())
 			time.Sleep(time.Second * 5)
 		}
 	}()
@@ -113,6 +161,18 @@ func main() {
 }
 
 func handleStart(m *tb.Message) {
+	if m.Private() {
+		subscribe(m)
+
+		if m.Payload == vote.VoteStartPayload {
+			if err := bot.SendVote(m.Sender); err != nil {
+				log.Printf("cannot send vote: %s", err)
+				bot.Send(m.Sender, bot.T(m.Chat.ID, "no_vote"))
+			}
+		}
+		return
+	}
+
 	if m.FromGroup() {
 		bot.Channel = m.Chat
 
@@ -127,15 +187,22 @@ func handleStart(m *tb.Message) {
 }
 
 func handleStartOnChannel(m *tb.Message) {
-	if strings.ToLower(m.Sender.Username) != bot.Config.God {
-		log.Printf("Cannot start need: %s, got: %s", m.Sender.Username, bot.Config.God)
+	chatID := m.Payload
+
+	chat, err := bot.ChatByID(chatID)
+	if err != nil || chat == nil {
+		log.Printf("cannot get chat %s: %v", chatID, err)
+		if bot.IsOwner(m.Sender) {
+			bot.Send(m.Sender, bot.T(m.Chat.ID, "chat_not_found", chatID))
+		}
 		return
 	}
 
-	log.Println("HANDLE START")
-	chatID := m.Payload
-
-	chat, _ := bot.ChatByID(chatID)
+	// owners start the bot anywhere, admins only in chats they administer.
+	if !bot.IsOwner(m.Sender) && !bot.HasRole(chat, m.Sender, vote.RoleAdmin) {
+		log.Printf("Cannot start, %s (%d) is not an owner or admin of chat %s", m.Sender.Username, m.Sender.ID, chatID)
+		return
+	}
 
 	bot.Channel = chat
 
@@ -147,25 +214,58 @@ func handleStartOnChannel(m *tb.Message) {
 		log.Printf("no pinned message found")
 		return
 	}
+	bot.Pinned = pm
 
-	log.Printf("pm = %+v\n", pm)
+	bot.Send(m.Sender, fmt.Sprintf("chatID: %d", chat.ID))
+}
 
-	if pm != nil {
-		bot.Pinned = pm
+// roleChat - chat the roles of message sender are checked in,
+// commands sent privately are applied to the vote channel.
+func roleChat(m *tb.Message) *tb.Chat {
+	if m.Private() {
+		return bot.Channel
 	}
 
-	bot.Send(m.Sender, fmt.Sprintf("chatID: %d", chat.ID))
+	return m.Chat
 }
 
-func handleSetDate(m *tb.Message) {
-	allowedUsers := make(map[string]bool, len(bot.Config.Admins))
-	for _, admin := range bot.Config.Admins {
-		allowedUsers[admin] = true
+// checkRole - report whether sender has at least `role`, answer with denial otherwise.
+func checkRole(m *tb.Message, role string) bool {
+	if !bot.HasRole(roleChat(m), m.Sender, role) {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "permission_denied"))
+		return false
+	}
+
+	return true
+}
+
+// checkAdmin - report whether sender is an admin, answer with denial otherwise.
+func checkAdmin(m *tb.Message) bool {
+	return checkRole(m, vote.RoleAdmin)
+}
+
+func handleLang(m *tb.Message) {
+	if !m.Private() && !checkAdmin(m) {
+		return
+	}
+
+	lang := strings.ToLower(strings.TrimSpace(m.Payload))
+	if err := bot.SetLanguage(m.Chat.ID, lang); err != nil {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "lang.usage", strings.Join(vote.Languages(), ", ")))
+		return
+	}
+
+	if m.FromGroup() {
+		if err := bot.UpdateVote(); err != nil {
+			log.Printf("caught err: %s", err)
+		}
 	}
 
-	sender := strings.ToLower(m.Sender.Username)
-	if _, ok := allowedUsers[sender]; !ok {
-		bot.Send(m.Sender, "Permission denied, Пёс")
+	bot.Send(m.Chat, bot.T(m.Chat.ID, "lang.changed"))
+}
+
+func handleSetDate(m *tb.Message) {
+	if !checkAdmin(m) {
 		return
 	}
 
@@ -204,39 +304,119 @@ func handleSetDate(m *tb.Message) {
 		formatError(m)
 		return
 	}
-	bot.Config.Weekday = wd
-	bot.Config.Hour = hour
-	bot.Config.Minute = min
 
-	bot.UpdateVote()
-	bot.Send(m.Sender, "👌")
+	if bot.Channel == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	if err := bot.SetSchedule(bot.Channel.ID, wd, hour, min); err != nil {
+		log.Printf("cannot save schedule: %s", err)
+		bot.Send(m.Sender, err.Error())
+		return
+	}
+
+	if err := bot.UpdateVote(); err != nil {
+		log.Printf("caught err: %s", err)
+	}
+	bot.Send(m.Sender, bot.T(m.Chat.ID, "ok"))
+}
+
+func handleTimezone(m *tb.Message) {
+	if !checkAdmin(m) {
+		return
+	}
+
+	if bot.Channel == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	name := strings.TrimSpace(m.Payload)
+	if err := bot.SetTimezone(bot.Channel.ID, name); err != nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "timezone.usage", err))
+		return
+	}
+
+	if err := bot.UpdateVote(); err != nil {
+		log.Printf("caught err: %s", err)
+	}
+	bot.Send(m.Sender, bot.T(m.Chat.ID, "ok"))
 }
 
 func handleCreate(m *tb.Message) {
-	allowedUsers := make(map[string]bool, len(bot.Config.Admins))
-	for _, admin := range bot.Config.Admins {
-		allowedUsers[admin] = true
+	if !checkAdmin(m) {
+		return
+	}
+
+	if err := bot.ReplaceVote(); err != nil {
+		log.Printf("caught error: %s", err)
+	}
+}
+
+func handleReload(m *tb.Message) {
+	if !checkAdmin(m) {
+		return
 	}
 
-	sender := strings.ToLower(m.Sender.Username)
-	if _, ok := allowedUsers[sender]; !ok {
-		bot.Send(m.Sender, "Permission denied, Пёс")
+	c, err := loader.Load()
+	if err != nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "reload.failed", err))
 		return
 	}
 
-	log.Println("Force unpin message")
+	if err := bot.SetConfig(c); err != nil {
+		log.Printf("caught err: %s", err)
+	}
+	bot.Send(m.Sender, bot.T(m.Chat.ID, "reload.ok"))
+}
+
+func handlePreview(m *tb.Message) {
+	if !checkAdmin(m) {
+		return
+	}
 
-	err := bot.UnpinMessage()
+	args := strings.Fields(m.Payload)
+	if len(args) == 0 {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "preview.usage", strings.Join(vote.PreviewKinds, "|")))
+		return
+	}
+	sample := len(args) > 1 && args[1] == "sample"
+
+	text, err := bot.Preview(args[0], sample)
 	if err != nil {
-		log.Printf("cannot upin, err: %v", err)
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "preview.failed", err))
+		return
 	}
 
-	log.Println("Force create vote")
-	if err := bot.CreateVote(); err != nil {
-		log.Printf("caught error: %s", err)
+	if _, err := bot.Send(m.Sender, text, bot.ParseMode()); err != nil {
+		// telegram rejects broken markup, show the raw text with the reason.
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "preview.failed", err))
+		bot.Send(m.Sender, text)
+	}
+}
+
+func handleSettings(m *tb.Message) {
+	chat := m.Chat
+	if m.Private() && bot.Channel != nil {
+		chat = bot.Channel
 	}
+
+	s, err := bot.Settings(chat.ID)
+	if err != nil {
+		log.Printf("cannot get settings: %s", err)
+		return
+	}
+
+	lines := []string{bot.T(m.Chat.ID, "settings.title")}
+	for _, key := range vote.SettingKeys {
+		source := bot.T(m.Chat.ID, "source."+s.Sources[key])
+		lines = append(lines, bot.T(m.Chat.ID, "settings.line", key, s.Value(key), source))
+	}
+
+	bot.Send(m.Chat, strings.Join(lines, "\n"))
 }
 
 func formatError(m *tb.Message) {
-	bot.Send(m.Sender, fmt.Sprintf("Лоховской формат: %s, нада типо Sunday 19:00", m.Payload))
+	bot.Send(m.Sender, bot.T(m.Chat.ID, "set_date.format", m.Payload))
 }
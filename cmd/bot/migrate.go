@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/pkg/errors"
+)
+
+const migrateUsage = `usage: vote-bot migrate <command>
+
+	up        - apply all pending migrations.
+	down [n]  - revert last n migrations (default 1).
+	status    - show applied and pending migrations.
+`
+
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	engine, err := model.GetEngine()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch args[0] {
+	case "up":
+		if err := engine.Migrate(); err != nil {
+			log.Fatal(errors.Wrap(err, "cannot apply migrations"))
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatalf("wrong number of steps: %s", args[1])
+			}
+			steps = n
+		}
+
+		if err := engine.Rollback(steps); err != nil {
+			log.Fatal(errors.Wrap(err, "cannot revert migrations"))
+		}
+	case "status":
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	states, err := engine.MigrationStatus()
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "cannot get migrations status"))
+	}
+
+	for _, s := range states {
+		status := "pending"
+		if s.Applied {
+			status = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%s\t%s\n", s.Migration, status)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// subscribe - opt sender of private message in for reminders about the vote channel.
+func subscribe(m *tb.Message) {
+	if bot.Channel == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	_, created, err := bot.Subscribe(bot.Channel.ID, m.Sender.ID)
+	if err != nil {
+		log.Printf("cannot subscribe: %s", err)
+		return
+	}
+	if created {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "notify.subscribed"))
+	}
+}
+
+func handleNotify(m *tb.Message) {
+	if !m.Private() {
+		return
+	}
+	if bot.Channel == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	sub, err := bot.Store.GetSubscription(bot.Channel.ID, m.Sender.ID)
+	if err != nil {
+		log.Printf("cannot get subscription: %s", err)
+		return
+	}
+	if sub == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "notify.not_subscribed"))
+		return
+	}
+
+	text, mkp := bot.NotifySettings(sub)
+	bot.Send(m.Sender, text, mkp)
+}
+
+func handleQuiet(m *tb.Message) {
+	if !m.Private() {
+		return
+	}
+	if bot.Channel == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	spec := strings.ToLower(strings.TrimSpace(m.Payload))
+	if err := bot.SetQuietHours(bot.Channel.ID, m.Sender.ID, spec); err != nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "notify.quiet_usage", err))
+		return
+	}
+
+	bot.Send(m.Sender, bot.T(m.Chat.ID, "ok"))
+}
+
+func handleStop(m *tb.Message) {
+	if !m.Private() || bot.Channel == nil {
+		return
+	}
+
+	if err := bot.Unsubscribe(bot.Channel.ID, m.Sender.ID); err != nil {
+		log.Printf("cannot unsubscribe: %s", err)
+		return
+	}
+
+	bot.Send(m.Sender, bot.T(m.Chat.ID, "notify.unsubscribed"))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	vote "github.com/k33nice/vote-bot/pkg"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// handleNick - set own nickname, admins set nickname of others
+// by replying to their message or with @username as the first argument.
+func handleNick(m *tb.Message) {
+	chat := roleChat(m)
+	if chat == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	user, nickname := m.Sender, m.Payload
+	if m.ReplyTo != nil || strings.HasPrefix(m.Payload, "@") {
+		if !checkAdmin(m) {
+			return
+		}
+
+		target, args, err := commandTarget(m, chat)
+		if err != nil {
+			bot.Send(m.Chat, bot.T(m.Chat.ID, "nick.usage", err))
+			return
+		}
+		user, nickname = target, strings.Join(args, " ")
+	}
+
+	if err := bot.SetNickname(chat.ID, user.ID, nickname); err != nil {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "nick.usage", err))
+		return
+	}
+
+	if err := bot.UpdateVote(); err != nil {
+		log.Printf("caught err: %s", err)
+	}
+	bot.Send(m.Chat, bot.T(m.Chat.ID, "ok"))
+}
+
+func handleDisplay(m *tb.Message) {
+	if !checkAdmin(m) {
+		return
+	}
+
+	chat := roleChat(m)
+	if chat == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	rule := strings.ToLower(strings.TrimSpace(m.Payload))
+	if err := bot.SetDisplayName(chat.ID, rule); err != nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "display.usage", strings.Join(vote.DisplayRules, "|"), err))
+		return
+	}
+
+	if err := bot.UpdateVote(); err != nil {
+		log.Printf("caught err: %s", err)
+	}
+	bot.Send(m.Sender, bot.T(m.Chat.ID, "ok"))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	vote "github.com/k33nice/vote-bot/pkg"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// handlePoll - start availability poll over candidate game times:
+// /poll 2024-06-01 18:00; 02.06 19:30, slots may also go on separate lines.
+func handlePoll(m *tb.Message) {
+	if !checkAdmin(m) {
+		return
+	}
+
+	if bot.Channel == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	// payload holds only the first line, slots may follow on the next ones.
+	text := strings.TrimSpace(m.Text)
+	if i := strings.IndexAny(text, " \n"); i >= 0 {
+		text = text[i+1:]
+	} else {
+		text = ""
+	}
+
+	settings, err := bot.Settings(bot.Channel.ID)
+	if err != nil {
+		log.Printf("cannot get settings: %s", err)
+		return
+	}
+	slots, err := vote.ParseSlots(text, settings.Location(), time.Now())
+	if err != nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "poll.usage", err))
+		return
+	}
+
+	if _, err := bot.CreatePoll(slots); err != nil {
+		log.Printf("cannot create poll: %s", err)
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "poll.usage", err))
+	}
+}
+
+// handlePollClose - close the latest poll, admins promote a slot from its results.
+func handlePollClose(m *tb.Message) {
+	if !checkAdmin(m) {
+		return
+	}
+
+	chat := roleChat(m)
+	if chat == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	if _, err := bot.ClosePoll(chat.ID); err != nil {
+		log.Printf("cannot close poll: %s", err)
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "poll.no_poll"))
+		return
+	}
+
+	bot.Send(m.Sender, bot.T(m.Chat.ID, "ok"))
+}
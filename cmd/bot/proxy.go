@@ -0,0 +1,159 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	vote "github.com/k33nice/vote-bot/pkg"
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// proxyTarget - player the vote is changed for: sender of the replied message,
+// @username or user id as the first argument, otherwise the arguments up to
+// a trailing vote option are the name of a guest without telegram.
+// Missing guests are created only with `create`. The vote option is returned as well.
+func proxyTarget(m *tb.Message, chat *tb.Chat, create bool) (*model.Player, string, error) {
+	args := strings.Fields(m.Payload)
+
+	first := ""
+	if len(args) > 0 {
+		first = args[0]
+	}
+	_, numErr := strconv.Atoi(first)
+	if m.ReplyTo != nil || strings.HasPrefix(first, "@") || numErr == nil {
+		user, rest, err := commandTarget(m, chat)
+		if err != nil {
+			return nil, "", err
+		}
+		player, err := bot.ProxyPlayer(chat.ID, user)
+		if err != nil {
+			return nil, "", err
+		}
+		return player, strings.Join(rest, " "), nil
+	}
+
+	option := ""
+	if len(args) > 1 {
+		if _, ok := vote.ParseVoteOption(args[len(args)-1]); ok {
+			option, args = args[len(args)-1], args[:len(args)-1]
+		}
+	}
+	name := strings.Join(args, " ")
+	if name == "" {
+		return nil, "", errors.New("player is not specified")
+	}
+
+	if create {
+		player, err := bot.Guest(chat.ID, name)
+		return player, option, err
+	}
+
+	player, err := bot.FindGuest(chat.ID, name)
+	if err == nil && player == nil {
+		err = errors.Errorf("player %q is not found", name)
+	}
+	return player, option, err
+}
+
+// handleAdd - vote on behalf of player who can't press the buttons,
+// agrees unless the option says otherwise.
+func handleAdd(m *tb.Message) {
+	if !checkAdmin(m) {
+		return
+	}
+
+	chat := roleChat(m)
+	if chat == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	player, option, err := proxyTarget(m, chat, true)
+	if err != nil {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "add.usage", err))
+		return
+	}
+
+	agree := true
+	if option != "" {
+		var ok bool
+		if agree, ok = vote.ParseVoteOption(option); !ok {
+			bot.Send(m.Chat, bot.T(m.Chat.ID, "add.usage", errors.Errorf("unknown option %q", option)))
+			return
+		}
+	}
+
+	if err := bot.CastVote(player, bot.VoteData(agree), m.Sender.ID); err != nil {
+		log.Printf("cannot cast vote: %s", err)
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "no_vote"))
+		return
+	}
+
+	bot.Send(m.Chat, bot.T(m.Chat.ID, "ok"))
+}
+
+// handleRemove - remove vote of player from the current vote.
+func handleRemove(m *tb.Message) {
+	if !checkAdmin(m) {
+		return
+	}
+
+	chat := roleChat(m)
+	if chat == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	player, _, err := proxyTarget(m, chat, false)
+	if err != nil {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "remove.usage", err))
+		return
+	}
+
+	if err := bot.RemoveVote(player, m.Sender.ID); err != nil {
+		log.Printf("cannot remove vote: %s", err)
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "no_vote"))
+		return
+	}
+
+	bot.Send(m.Chat, bot.T(m.Chat.ID, "ok"))
+}
+
+// handleAudit - send changes of the current vote to admin privately.
+func handleAudit(m *tb.Message) {
+	if !checkAdmin(m) {
+		return
+	}
+
+	entries, err := bot.VoteAudit()
+	if err != nil {
+		log.Printf("cannot get vote audit: %s", err)
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_vote"))
+		return
+	}
+
+	lines := []string{bot.T(m.Chat.ID, "audit.title")}
+	for _, e := range entries {
+		action := "audit.no"
+		switch {
+		case e.Removed:
+			action = "audit.removed"
+		case e.Agree:
+			action = "audit.yes"
+		}
+
+		line := bot.T(m.Chat.ID, "audit.line", e.At.Format("02.01 15:04"), e.Player, bot.T(m.Chat.ID, action))
+		if e.Proxied {
+			line += " " + bot.T(m.Chat.ID, "audit.by", e.Actor)
+		}
+		lines = append(lines, line)
+	}
+	if len(entries) == 0 {
+		lines = append(lines, bot.T(m.Chat.ID, "audit.empty"))
+	}
+
+	bot.Send(m.Sender, strings.Join(lines, "\n"))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	vote "github.com/k33nice/vote-bot/pkg"
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// handleStats - attendance, refusal reasons and late cancellations of chat players:
+// /stats [from] [to], dates are inclusive and all time by default.
+func handleStats(m *tb.Message) {
+	chat := roleChat(m)
+	if chat == nil {
+		bot.Send(m.Sender, bot.T(m.Chat.ID, "no_channel"))
+		return
+	}
+
+	from, to, rest, err := periodArgs(chat, strings.Fields(m.Payload))
+	if err == nil && len(rest) > 0 {
+		err = errors.Errorf("unknown argument %q", rest[0])
+	}
+	if err != nil {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "stats.usage", err))
+		return
+	}
+
+	stats, err := bot.Stats(chat.ID, from, to, bot.Language(m.Chat.ID))
+	if err != nil {
+		log.Printf("cannot get stats: %s", err)
+		return
+	}
+
+	lines := []string{bot.T(m.Chat.ID, "stats.title", stats.Games)}
+	for _, p := range stats.Players {
+		line := bot.T(m.Chat.ID, "stats.player", p.Name, p.Agreed, p.Refused)
+		if len(p.Reasons) > 0 {
+			line += " (" + formatReasons(p.Reasons) + ")"
+		}
+		if p.LateCancels > 0 {
+			line += ", " + bot.T(m.Chat.ID, "stats.late", p.LateCancels)
+		}
+		lines = append(lines, line)
+	}
+	if stats.LateCancels > 0 {
+		lines = append(lines, "", bot.T(m.Chat.ID, "stats.late_total", stats.LateCancels))
+	}
+	if len(stats.Reasons) > 0 {
+		lines = append(lines, "", bot.T(m.Chat.ID, "stats.reasons", formatReasons(stats.Reasons)))
+	}
+
+	bot.Send(m.Chat, strings.Join(lines, "\n"))
+}
+
+func formatReasons(reasons []vote.ReasonCount) string {
+	var list []string
+	for _, r := range reasons {
+		list = append(list, fmt.Sprintf("%s %d", r.Reason, r.Count))
+	}
+
+	return strings.Join(list, ", ")
+}
+
+// handleReason - explain refusal in the current vote: /reason injury.
+func handleReason(m *tb.Message) {
+	if err := bot.SetReason(m.Sender.ID, m.Payload); err != nil {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "reason.usage", err))
+		return
+	}
+
+	bot.Send(m.Chat, bot.T(m.Chat.ID, "ok"))
+}
+
+// handleText - private messages answer the question why the player refused.
+func handleText(m *tb.Message) {
+	if !m.Private() || !bot.AwaitsReason(m.Sender.ID) {
+		return
+	}
+
+	if err := bot.SetReason(m.Sender.ID, m.Text); err != nil {
+		bot.Send(m.Chat, bot.T(m.Chat.ID, "reason.usage", err))
+		return
+	}
+
+	bot.Send(m.Chat, bot.T(m.Chat.ID, "reason.saved", strings.TrimSpace(m.Text)))
+}
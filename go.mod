@@ -3,6 +3,7 @@ module github.com/k33nice/vote-bot
 require (
 	cloud.google.com/go v0.43.0 // indirect
 	github.com/denisenkom/go-mssqldb v0.0.0-20190724012636-11b2859924c1 // indirect
+	github.com/fsnotify/fsnotify v1.4.7
 	github.com/jinzhu/gorm v1.9.10
 	github.com/lib/pq v1.2.0 // indirect
 	github.com/magiconair/properties v1.8.1 // indirect
@@ -1,14 +1,13 @@
 package vote
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"html/template"
 	"log"
 	"math/rand"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/k33nice/vote-bot/pkg/model"
@@ -18,36 +17,93 @@ import (
 
 const userSymbol = "👤"
 const ballSymbol = "⚽️"
+const waitSymbol = "⏳"
 const shitSymbol = "💩"
 
-// HelpMessage - show help message.
-const HelpMessage = `
-	/help   - show this help message.
-	/start  - start bot.
-	/where  - show place where we playing.
-	/info   - show info.
-	/result - last vote result.
-`
-
 // Bot - represent a separate telegram bot instance.
 type Bot struct {
-	tb.Bot
+	Telegram
+
+	mu         sync.Mutex
+	chatAdmins map[int64]chatAdmins
+
+	// voteMu - serializes creation and replacement of the pinned vote.
+	voteMu sync.Mutex
+	// cfgMu - guards config, it is replaced on reload while handlers read it.
+	cfgMu  sync.RWMutex
+	config *Config
 
+	Me      *tb.User
 	Unique  int
-	Config  *Config
+	Store   model.Store
 	Vote    *Vote
 	Pinned  tb.Editable
 	Channel *tb.Chat
+
+	// Forecasts - weather source for vote data, used if enabled in config.
+	Forecasts WeatherProvider
+
+	// pending - direct messages held back by quiet hours, they are not stored
+	// and are lost on restart.
+	pending []notification
+	// awaitingReason - vote ids by users asked why they refused.
+	awaitingReason map[int]int
+	// nextGame - date of the special game the next vote is created for.
+	nextGame time.Time
+	// gameDates - dates of special games by vote ids, zero for scheduled games.
+	gameDates map[int]time.Time
 }
 
-// NewBot - return new Bot instance.
-func NewBot(s tb.Settings, config *Config) (*Bot, error) {
+// NewBot - return new Bot instance connected to telegram.
+func NewBot(s tb.Settings, config *Config, store model.Store) (*Bot, error) {
 	b, err := tb.NewBot(s)
 
 	if err != nil {
 		return nil, err
 	}
-	return &Bot{Bot: *b, Config: config, Unique: getRandInt()}, nil
+	bot := NewBotWith(b, b.Me, config, store)
+	bot.Forecasts = NewOpenMeteo()
+
+	return bot, nil
+}
+
+// NewBotWith - return new Bot instance working through passed telegram api as user `me`.
+func NewBotWith(tg Telegram, me *tb.User, config *Config, store model.Store) *Bot {
+	return &Bot{
+		Telegram:       tg,
+		Me:             me,
+		config:         config,
+		Store:          store,
+		Unique:         getRandInt(),
+		chatAdmins:     map[int64]chatAdmins{},
+		awaitingReason: map[int]int{},
+		gameDates:      map[int]time.Time{},
+	}
+}
+
+// Config - current config, it is replaced as a whole on reload and must not be changed.
+func (b *Bot) Config() *Config {
+	b.cfgMu.RLock()
+	defer b.cfgMu.RUnlock()
+
+	return b.config
+}
+
+// SetConfig - apply new config keeping the current vote, its appeal survives
+// even if it is removed from the new config until the next vote.
+func (b *Bot) SetConfig(c *Config) error {
+	b.cfgMu.Lock()
+	c.APIToken = b.config.APIToken
+	b.config = c
+	b.cfgMu.Unlock()
+
+	b.mu.Lock()
+	if b.Vote != nil {
+		b.Vote.Format = c.Formats.VoteFormat
+	}
+	b.mu.Unlock()
+
+	return b.UpdateVote()
 }
 
 func getRandInt() int {
@@ -62,34 +118,82 @@ type Vote struct {
 }
 
 func (b *Bot) getButtons() (*tb.InlineButton, *tb.InlineButton) {
+	lang := b.channelLanguage()
+
 	yesBtn := tb.InlineButton{
 		Unique: fmt.Sprintf("yes_%d", b.Unique),
-		Text:   "Да",
+		Text:   translate(lang, "btn.yes"),
 		Data:   "1",
 	}
 
 	noBtn := tb.InlineButton{
 		Unique: fmt.Sprintf("no_%d", b.Unique),
-		Text:   "Нет",
+		Text:   translate(lang, "btn.no"),
 		Data:   "0",
 	}
 
 	return &yesBtn, &noBtn
 }
 
+func (b *Bot) channelLanguage() string {
+	return b.channelSettings().Language
+}
+
 // CreateVote - creating new message for voting.
 func (b *Bot) CreateVote() error {
+	b.voteMu.Lock()
+	defer b.voteMu.Unlock()
+
+	return b.createVote(time.Time{})
+}
+
+// ReplaceVote - unpin the current vote and create a new one.
+func (b *Bot) ReplaceVote() error {
+	b.voteMu.Lock()
+	defer b.voteMu.Unlock()
+
+	return b.replaceVote(time.Time{})
+}
+
+func (b *Bot) replaceVote(special time.Time) error {
+	log.Println("Force unpin message")
+	if err := b.UnpinMessage(); err != nil {
+		log.Printf("cannot upin, err: %v", err)
+	}
+
+	log.Println("Force create vote")
+	return b.createVote(special)
+}
+
+// createVote - create the vote for the scheduled game, or for the game at `special`
+// if it is set, must be called with voteMu held.
+func (b *Bot) createVote(special time.Time) error {
 	if b.Channel == nil {
 		return errors.New("No channel")
 	}
 
+	// the new vote is rendered before it is pinned, GameDate takes the date from here.
+	b.mu.Lock()
+	b.nextGame = special
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		b.nextGame = time.Time{}
+		b.mu.Unlock()
+	}()
+
+	cfg := b.Config()
 	rand.Seed(time.Now().UnixNano())
-	i := rand.Intn(len(b.Config.Appeals))
+	i := rand.Intn(len(cfg.Appeals))
 
-	b.Vote = &Vote{Format: b.Config.Formats.VoteFormat, RandAppeal: b.Config.Appeals[i]}
+	b.Vote = &Vote{Format: cfg.Formats.VoteFormat, RandAppeal: cfg.Appeals[i]}
 	b.Unique = getRandInt()
 
-	msg, mrk, parseMode := b.getVoteMessage()
+	msg, mrk, parseMode, err := b.getVoteMessage()
+	if err != nil {
+		return err
+	}
+
 	m, err := b.Send(b.Channel, msg, mrk, parseMode)
 	if err != nil {
 		return errors.Wrap(err, "cannot send message to channel during a vote creation")
@@ -102,6 +206,15 @@ func (b *Bot) CreateVote() error {
 		return errors.Wrap(err, "cannot pin message")
 	}
 
+	save := b.saveGame
+	if !special.IsZero() {
+		save = func() error { return b.saveSpecialGame(special) }
+	}
+	if err := save(); err != nil {
+		log.Printf("cannot save game: %s", err)
+	}
+	b.notify(NotifyVoteOpen, "notify.vote_open", nil, true)
+
 	return nil
 }
 
@@ -111,23 +224,48 @@ func (b *Bot) CreateHandlers() {
 	b.Handle(yB, b.buttonHandler(*yB))
 
 	b.Handle(nB, b.buttonHandler(*nB))
+
+	for _, kind := range NotifyKinds {
+		b.Handle(&tb.InlineButton{Unique: "notify_" + kind}, b.notifyButtonHandler)
+	}
+	b.Handle(&tb.InlineButton{Unique: "reason"}, b.reasonButtonHandler)
+
+	dB, rB := b.getCarpoolButtons()
+	b.Handle(dB, b.carpoolHandler(true))
+	b.Handle(rB, b.carpoolHandler(false))
+	b.Handle(b.getDutyButton(), b.dutyHandler)
+	b.Handle(&tb.InlineButton{Unique: "poll"}, b.pollHandler)
+	b.Handle(&tb.InlineButton{Unique: "poll_promote"}, b.promoteHandler)
 }
 
 func (b *Bot) buttonHandler(btn tb.InlineButton) func(*tb.Callback) {
 	return func(c *tb.Callback) {
 		b.Respond(c, &tb.CallbackResponse{Text: btn.Text})
+		if b.Channel == nil {
+			return
+		}
+
+		player, err := b.RegisterPlayer(b.Channel.ID, c.Sender)
+		if err != nil {
+			log.Printf("cannot save player: %s", err)
+			return
+		}
 
-		model.CreateVote(&model.Vote{
-			VoteID:     b.getMsgID(),
-			UserID:     c.Sender.ID,
-			VoterName:  c.Sender.Username,
-			FirstName:  c.Sender.FirstName,
-			LastName:   c.Sender.LastName,
-			PressedBtn: c.Data,
-		})
+		refused, err := b.refusal(c.Sender.ID)
+		if err != nil {
+			log.Printf("cannot get vote: %s", err)
+			return
+		}
+
+		if err := b.CastVote(player, c.Data, c.Sender.ID); err != nil {
+			log.Printf("caught err: %s", err)
+			return
+		}
 
-		newMsg, mkp, parseMode := b.getVoteMessage()
-		b.Edit(b.Pinned, newMsg, mkp, parseMode)
+		// only a new refusal is asked why, repeated presses keep the reason.
+		if yesBtn, _ := b.getButtons(); c.Data != yesBtn.Data && refused == nil {
+			b.askReason(c.Sender)
+		}
 	}
 }
 
@@ -209,140 +347,133 @@ func (b *Bot) UnpinMessage() error {
 
 // UpdateVote - update vote.
 func (b *Bot) UpdateVote() error {
+	if b.Pinned == nil {
+		return nil
+	}
+
 	if b.Vote == nil {
+		cfg := b.Config()
 		rand.Seed(time.Now().UnixNano())
-		i := rand.Intn(len(b.Config.Appeals))
-		b.Vote = &Vote{Format: b.Config.Formats.ResultFormat, RandAppeal: b.Config.Appeals[i]}
+		i := rand.Intn(len(cfg.Appeals))
+		b.Vote = &Vote{Format: cfg.Formats.ResultFormat, RandAppeal: cfg.Appeals[i]}
+	}
+
+	newMsg, mkp, parseMode, err := b.getVoteMessage()
+	if err != nil {
+		return err
 	}
 
-	newMsg, mkp, parseMode := b.getVoteMessage()
-	b.Edit(b.Pinned, newMsg, mkp, parseMode)
+	// the loop re-renders the vote every few seconds, unchanged text is not an error.
+	if _, err := b.Edit(b.Pinned, newMsg, mkp, parseMode); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		return errors.Wrap(err, "cannot edit vote message")
+	}
 
 	return nil
 }
 
 // GetVoteResult - return result of current vote.
-func (b *Bot) GetVoteResult() string {
-	result := b.Config.NoResult
+func (b *Bot) GetVoteResult() (string, error) {
+	result := b.Config().NoResult
 	if b.Pinned != nil {
-		yesBtn, _ := b.getButtons()
-
-		results := model.GetVoteResult(b.getMsgID())
-
-		agree := 0
-		disagree := 0
-		for _, r := range results {
-			if r.PressedBtn == yesBtn.Data {
-				agree = r.Count
-			} else {
-				disagree = r.Count
-			}
+		data, err := b.voteData()
+		if err != nil {
+			return "", err
 		}
 
-		t := template.Must(template.New("").Parse(b.Config.Formats.ResultFormat))
-		data := map[string]int{
-			"Agree":    agree,
-			"Disagree": disagree,
+		result, err = b.render(b.Config().Formats.ResultFormat, data)
+		if err != nil {
+			return "", errors.Wrap(err, "cannot render result")
 		}
-		result = execTpl(t, data)
 	}
 
-	return result
+	return result, nil
 }
 
 // SendReminder - send reminder for players.
-func (b *Bot) SendReminder() {
+func (b *Bot) SendReminder() error {
 	if b.Pinned != nil {
-		rem := model.GetReminderByVoteID(b.getMsgID())
+		rem, err := b.Store.GetReminderByVoteID(b.getMsgID(), reminderGroup)
+		if err != nil {
+			return err
+		}
 
 		if rem != nil {
-			return
+			return nil
 		}
 
-		voters := model.GetVotesByVoteID(b.getMsgID())
-		yesBtn, _ := b.getButtons()
+		data, err := b.voteData()
+		if err != nil {
+			return err
+		}
 
-		var users []string
-		for _, voter := range voters {
-			if voter.PressedBtn == yesBtn.Data {
-				users = append(users, fmt.Sprintf("[%s %s](tg://user?id=%d)", voter.FirstName, voter.LastName, voter.UserID))
+		if data.Going.Count >= 8 {
+			result, err := b.render(b.Config().Formats.RemindFormat, data)
+			if err != nil {
+				return errors.Wrap(err, "cannot render reminder")
 			}
-		}
 
-		if len(users) >= 8 {
-			t := template.Must(template.New("").Parse(b.Config.Formats.RemindFormat))
-			data := map[string]string{
-				"Appeal": b.Vote.RandAppeal,
-				"Users":  strings.Join(users, " "),
+			if _, err := b.Send(b.Channel, result, b.markup().parseMode()); err != nil {
+				return errors.Wrap(err, "cannot send reminder")
 			}
-			result := execTpl(t, data)
 
-			b.Send(b.Channel, result, tb.ModeMarkdown)
+			if _, err := b.Store.CreateReminder(b.getMsgID(), reminderGroup); err != nil {
+				return err
+			}
 		}
 	}
+
+	return nil
 }
 
-func (b *Bot) getVoteMessage() (string, *tb.ReplyMarkup, tb.ParseMode) {
+func (b *Bot) getVoteMessage() (string, *tb.ReplyMarkup, tb.ParseMode, error) {
 	yB, nB := b.getButtons()
 	inlineKeys := [][]tb.InlineButton{
 		[]tb.InlineButton{*yB},
 		[]tb.InlineButton{*nB},
 	}
+	if b.Config().Carpool.Seats > 0 {
+		dB, rB := b.getCarpoolButtons()
+		inlineKeys = append(inlineKeys, []tb.InlineButton{*dB, *rB})
+	}
+	if len(b.Config().Duties) > 0 && b.Pinned != nil {
+		duties, err := b.Store.GetVoteDuties(b.getMsgID())
+		if err != nil {
+			return "", nil, "", err
+		}
+		if len(duties) > 0 {
+			inlineKeys = append(inlineKeys, []tb.InlineButton{*b.getDutyButton()})
+		}
+	}
 
-	return b.voteCaption(), &tb.ReplyMarkup{InlineKeyboard: inlineKeys}, tb.ModeMarkdown
-}
-
-func (b *Bot) voteCaption() string {
-	var agree string
-	var disagree string
-	agCount := 0
-	dgCount := 0
+	caption, err := b.voteCaption()
+	if err != nil {
+		return "", nil, "", err
+	}
 
-	yesBtn, noBtn := b.getButtons()
-	if b.Pinned != nil {
-		voters := model.GetVotesByVoteID(b.getMsgID())
-		for _, voter := range voters {
-
-			var symbol string
-			if voter.PressedBtn == yesBtn.Data {
-				agCount++
-				symbol = ballSymbol
-			} else {
-				dgCount++
-				symbol = shitSymbol
-			}
+	return caption, &tb.ReplyMarkup{InlineKeyboard: inlineKeys}, b.markup().parseMode(), nil
+}
 
-			str := fmt.Sprintf(
-				"\n %s [%s %s](tg://user?id=%d)",
-				symbol,
-				voter.FirstName,
-				voter.LastName,
-				voter.UserID,
-			)
-
-			if voter.PressedBtn == yesBtn.Data {
-				agree += str
-			} else {
-				disagree += str
-			}
-		}
+func (b *Bot) voteCaption() (string, error) {
+	data, err := b.voteData()
+	if err != nil {
+		return "", err
 	}
 
-	t := template.Must(template.New("").Parse(b.Config.Formats.VoteFormat))
+	format := b.Config().Formats.VoteFormat
+	caption, err := b.render(format, data)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot render vote")
+	}
 
-	data := map[string]interface{}{
-		"Symbols":       strings.Repeat(userSymbol, agCount),
-		"Appeal":        b.Vote.RandAppeal,
-		"Yes":           yesBtn.Text,
-		"Agree":         agCount,
-		"AgreeNames":    agree,
-		"No":            noBtn.Text,
-		"Disagree":      dgCount,
-		"DisagreeNames": disagree,
-		"Date":          getDate(b.Config.Weekday, b.Config.Hour, b.Config.Minute).Format("2006-01-02 15:04"),
+	// formats without their own carpool or duties section get the default one.
+	if data.Carpool != nil && !data.Carpool.Empty() && !strings.Contains(format, ".Carpool") {
+		caption += "\n\n" + b.carpoolCaption(data.Carpool)
+	}
+	if len(data.Duties) > 0 && !strings.Contains(format, ".Duties") {
+		caption += "\n\n" + b.dutiesCaption(data.Duties)
 	}
 
-	return execTpl(t, data)
+	return caption, nil
 }
 
 func (b *Bot) getMsgID() int {
@@ -352,31 +483,50 @@ func (b *Bot) getMsgID() int {
 	return id
 }
 
-func getDate(wd, hour, minute int) time.Time {
-	date := time.Now()
-
-	weekday := int(date.Weekday())
-	loc, _ := time.LoadLocation("Europe/Kiev")
+// getDate - return the first game time on weekday `wd` at hour:minute
+// in location `loc` that is not before `from`. The date is built from
+// the wall clock, so the game time survives DST transitions.
+func getDate(from time.Time, loc *time.Location, wd, hour, minute int) time.Time {
+	from = from.In(loc)
 
-	magicNum := 7
-	if wd != int(time.Sunday) {
-		magicNum = wd
-	}
-
-	diff := (magicNum - weekday)
+	diff := wd - int(from.Weekday())
 	if diff < 0 {
 		diff += 7
 	}
 
-	if weekday != 0 {
-		date = date.AddDate(0, 0, diff)
+	date := time.Date(from.Year(), from.Month(), from.Day()+diff, hour, minute, 0, 0, loc)
+	if date.Before(from) {
+		date = time.Date(from.Year(), from.Month(), from.Day()+diff+7, hour, minute, 0, 0, loc)
+	}
+
+	return date
+}
+
+// pinnedInfo - return author username and send time of the pinned message.
+func (b *Bot) pinnedInfo() (string, time.Time) {
+	switch m := b.Pinned.(type) {
+	case *tb.Message:
+		return m.Sender.Username, m.Time()
+	case *PinnedMessage:
+		return m.From.Username, time.Unix(int64(m.Date), 0)
 	}
 
-	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, loc)
+	return "", time.Time{}
 }
 
-func execTpl(t *template.Template, data interface{}) string {
-	buf := bytes.Buffer{}
-	t.Execute(&buf, data)
-	return buf.String()
+// GameDate - return time of the game of the current vote in the channel timezone,
+// it is counted from the vote creation so it doesn't move during the week.
+// Special games promoted from a poll keep their own date.
+func (b *Bot) GameDate() time.Time {
+	settings := b.channelSettings()
+	if date, ok := b.specialGame(); ok {
+		return date.In(settings.Location())
+	}
+
+	from := time.Now()
+	if b.Pinned != nil {
+		_, from = b.pinnedInfo()
+	}
+
+	return getDate(from, settings.Location(), settings.Weekday, settings.Hour, settings.Minute)
 }
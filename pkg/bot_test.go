@@ -0,0 +1,156 @@
+package vote
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/k33nice/vote-bot/pkg/teletest"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+var (
+	testMe      = &tb.User{ID: 1, Username: "votebot"}
+	testChannel = &tb.Chat{ID: -100, Type: tb.ChatGroup}
+	testAdmin   = &tb.User{ID: 2, FirstName: "Admin"}
+	testMax     = &tb.User{ID: 10, FirstName: "Max"}
+	testBob     = &tb.User{ID: 11, FirstName: "Bob"}
+)
+
+// newTestBot - bot in the test channel over fake telegram and memory store,
+// `configure` may change the config before the bot is created.
+func newTestBot(t *testing.T, configure func(*Config)) (*Bot, *teletest.Telegram) {
+	t.Helper()
+
+	tg := teletest.NewTelegram(testMe)
+	tg.AddChat(testChannel)
+
+	cfg := &Config{Appeals: []string{"Game!"}, Weekday: 3, Hour: 19, Timezone: "UTC", Admins: []int{testAdmin.ID}}
+	cfg.Formats.VoteFormat = "{{.AgreeNames}}|{{.DisagreeNames}}"
+	if configure != nil {
+		configure(cfg)
+	}
+
+	b := NewBotWith(tg, testMe, cfg, model.NewMemoryStore())
+	b.Channel = testChannel
+
+	return b, tg
+}
+
+// pinned - the pinned vote of the test channel.
+func pinned(t *testing.T, tg *teletest.Telegram) *teletest.Message {
+	t.Helper()
+
+	m := tg.Pinned(testChannel.ID)
+	if m == nil {
+		t.Fatal("no pinned vote")
+	}
+
+	return m
+}
+
+// press - press vote button `btn` like yes or no of the pinned vote as `user`.
+func press(t *testing.T, b *Bot, tg *teletest.Telegram, btn string, user *tb.User) {
+	t.Helper()
+
+	if err := tg.Press(pinned(t, tg).Message, fmt.Sprintf("%s_%d", btn, b.Unique), user); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// caption - agree and disagree names of the pinned vote.
+func caption(t *testing.T, tg *teletest.Telegram) (string, string) {
+	t.Helper()
+
+	parts := strings.SplitN(pinned(t, tg).Text, "|", 2)
+	if len(parts) != 2 {
+		t.Fatalf("unexpected caption %q", pinned(t, tg).Text)
+	}
+
+	return parts[0], parts[1]
+}
+
+func TestVoteLifecycle(t *testing.T) {
+	b, tg := newTestBot(t, nil)
+
+	now := time.Now().UTC()
+	b.Tick(now)
+	vote := pinned(t, tg)
+	if vote.Markup == nil || len(vote.Markup.InlineKeyboard) != 2 {
+		t.Fatalf("vote has no yes and no buttons: %+v", vote.Markup)
+	}
+
+	press(t, b, tg, "yes", testMax)
+	agree, disagree := caption(t, tg)
+	if !strings.Contains(agree, "Max") || strings.Contains(disagree, "Max") {
+		t.Errorf("Max is not going: %q | %q", agree, disagree)
+	}
+
+	edited := len(tg.Edited)
+	press(t, b, tg, "no", testMax)
+	if len(tg.Edited) <= edited {
+		t.Error("vote is not edited after the second press")
+	}
+	agree, disagree = caption(t, tg)
+	if strings.Contains(agree, "Max") || !strings.Contains(disagree, "Max") {
+		t.Errorf("Max is going: %q | %q", agree, disagree)
+	}
+
+	// the same week keeps the vote.
+	b.Tick(now)
+	if got := pinned(t, tg); got.ID != vote.ID {
+		t.Fatalf("vote %d is replaced by %d in the same week", vote.ID, got.ID)
+	}
+
+	next := time.Date(now.Year(), now.Month(), now.Day()+7, 16, 0, 0, 0, time.UTC)
+	b.Tick(next)
+	got := pinned(t, tg)
+	if got.ID == vote.ID {
+		t.Fatal("last week vote is not unpinned")
+	}
+	if agree, disagree := caption(t, tg); strings.Contains(agree+disagree, "Max") {
+		t.Errorf("new vote has votes of the last one: %q | %q", agree, disagree)
+	}
+}
+
+func TestGetDateDST(t *testing.T) {
+	kiev, err := loadTimezone("Europe/Kiev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(s string) time.Time {
+		t.Helper()
+		d, err := time.ParseInLocation("2006-01-02 15:04", s, kiev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name string
+		from time.Time
+		want time.Time
+		// offset - UTC offset of the game in hours.
+		offset int
+	}{
+		{"before spring", at("2024-03-25 12:00"), at("2024-03-31 19:00"), 3},
+		{"week across spring", at("2024-03-24 20:00"), at("2024-03-31 19:00"), 3},
+		{"spring night", at("2024-03-31 02:00"), at("2024-03-31 19:00"), 3},
+		{"before autumn", at("2024-10-21 12:00"), at("2024-10-27 19:00"), 2},
+		{"week across autumn", at("2024-10-20 20:00"), at("2024-10-27 19:00"), 2},
+		{"autumn night", at("2024-10-27 03:30"), at("2024-10-27 19:00"), 2},
+		{"after autumn game", at("2024-10-27 19:01"), at("2024-11-03 19:00"), 2},
+	}
+	for _, tt := range tests {
+		got := getDate(tt.from, kiev, int(time.Sunday), 19, 0)
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+		if _, offset := got.Zone(); offset != tt.offset*3600 || got.Hour() != 19 {
+			t.Errorf("%s: game at %s is not 19:00 at UTC+%d", tt.name, got, tt.offset)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package vote
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// lateCancel - record refusal of player confirmed in `before` as a late
+// cancellation if the player made it at `now` within LateCancel.Hours before the game,
+// and charge the fee. Every player is charged once per vote, changes made by admins
+// on behalf of the player are not charged.
+func (b *Bot) lateCancel(before *VoteData, player *model.Player, actorID int, now time.Time) {
+	hours := b.Config().LateCancel.Hours
+	if hours == 0 || b.Channel == nil || actorID != player.UserID {
+		return
+	}
+
+	game := b.GameDate()
+	if now.Before(game.Add(-time.Duration(hours)*time.Hour)) || !now.Before(game) {
+		return
+	}
+
+	confirmed := false
+	for _, v := range before.Going.Voters {
+		if v.ID == player.UserID {
+			confirmed = true
+		}
+	}
+	if !confirmed {
+		return
+	}
+
+	old, err := b.Store.GetLateCancellation(b.getMsgID(), player.UserID)
+	if err != nil || old != nil {
+		if err != nil {
+			log.Printf("cannot get late cancellation: %s", err)
+		}
+		return
+	}
+
+	fee := b.Config().LateCancel.Fee
+	err = b.Store.AddLateCancellation(&model.LateCancellation{
+		ChatID:   b.Channel.ID,
+		VoteID:   b.getMsgID(),
+		UserID:   player.UserID,
+		PlayerID: player.ID,
+		Fee:      fee,
+	})
+	if err != nil {
+		log.Printf("cannot add late cancellation: %s", err)
+		return
+	}
+
+	if fee > 0 {
+		if err := b.Store.AddBalance(b.Channel.ID, player.UserID, -fee); err != nil {
+			log.Printf("cannot charge late cancellation: %s", err)
+		}
+	}
+}
+
+// Balance - balance of user in chat, 0 for unknown players.
+func (b *Bot) Balance(chatID int64, userID int) (int, error) {
+	p, err := b.Store.GetPlayer(chatID, userID)
+	if err != nil || p == nil {
+		return 0, err
+	}
+
+	return p.Balance, nil
+}
+
+// AddBalance - add `amount` to balance of user in chat, the player is
+// registered if it is not known yet.
+func (b *Bot) AddBalance(chatID int64, user *tb.User, amount int) error {
+	if _, err := b.ProxyPlayer(chatID, user); err != nil {
+		return err
+	}
+
+	return b.Store.AddBalance(chatID, user.ID, amount)
+}
+
+// PlayerBalance - player with non-zero balance.
+type PlayerBalance struct {
+	ID      int
+	Name    string
+	Balance int
+}
+
+// Balances - players of chat with non-zero balance, debtors first.
+func (b *Bot) Balances(chatID int64) ([]PlayerBalance, error) {
+	settings, err := b.Settings(chatID)
+	if err != nil {
+		return nil, err
+	}
+	players, err := b.Store.GetPlayers(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []PlayerBalance
+	for _, p := range players {
+		if p.Balance != 0 {
+			list = append(list, PlayerBalance{ID: p.UserID, Name: displayName(p, settings.DisplayName), Balance: p.Balance})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Balance < list[j].Balance })
+
+	return list, nil
+}
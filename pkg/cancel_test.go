@@ -0,0 +1,85 @@
+package vote
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLateCancel(t *testing.T) {
+	// every refusal during the week is late.
+	b, tg := newTestBot(t, func(c *Config) {
+		c.LateCancel.Hours = 8 * 24
+		c.LateCancel.Fee = 100
+	})
+	b.Tick(time.Now())
+
+	balance := func(userID int) int {
+		t.Helper()
+		n, err := b.Balance(testChannel.ID, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	press(t, b, tg, "yes", testMax)
+	press(t, b, tg, "no", testMax)
+	if n := balance(testMax.ID); n != -100 {
+		t.Errorf("balance of late canceller is %d, want -100", n)
+	}
+
+	// the fee is charged once per vote.
+	press(t, b, tg, "yes", testMax)
+	press(t, b, tg, "no", testMax)
+	if n := balance(testMax.ID); n != -100 {
+		t.Errorf("balance after the second cancel is %d, want -100", n)
+	}
+
+	// refusal without agreeing first is not a cancellation.
+	press(t, b, tg, "no", testBob)
+	if n := balance(testBob.ID); n != 0 {
+		t.Errorf("balance of player who never agreed is %d, want 0", n)
+	}
+}
+
+func TestLateCancelDisabled(t *testing.T) {
+	b, tg := newTestBot(t, nil)
+	b.Tick(time.Now())
+
+	press(t, b, tg, "yes", testMax)
+	press(t, b, tg, "no", testMax)
+	if n, err := b.Balance(testChannel.ID, testMax.ID); err != nil || n != 0 {
+		t.Errorf("balance is %d (%v), want 0", n, err)
+	}
+}
+
+func TestLateCancelByAdmin(t *testing.T) {
+	b, tg := newTestBot(t, func(c *Config) {
+		c.LateCancel.Hours = 8 * 24
+		c.LateCancel.Fee = 100
+	})
+	b.Tick(time.Now())
+
+	player, err := b.ProxyPlayer(testChannel.ID, testMax)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// admin undoes the mistaken vote.
+	if err := b.CastVote(player, b.VoteData(true), testAdmin.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RemoveVote(player, testAdmin.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// and records the refusal the player sent privately.
+	press(t, b, tg, "yes", testMax)
+	if err := b.CastVote(player, b.VoteData(false), testAdmin.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := b.Balance(testChannel.ID, testMax.ID); err != nil || n != 0 {
+		t.Errorf("balance is %d (%v), want 0", n, err)
+	}
+}
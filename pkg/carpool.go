@@ -0,0 +1,265 @@
+package vote
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// maxSeats - most seats a driver can offer.
+const maxSeats = 8
+
+const carSymbol = "🚗"
+
+// reminderCarpool - kind of the reminder recorded when drivers get their passengers.
+const reminderCarpool = "carpool"
+
+// Carpool - drivers of the current vote with passengers matched to them.
+type Carpool struct {
+	// Drivers - confirmed players offering seats in order of their offers.
+	Drivers []Driver
+	// Unmatched - confirmed players who need a ride and got no seat.
+	Unmatched []Voter
+}
+
+// Driver - player offering seats with passengers matched to them.
+type Driver struct {
+	Voter
+	Seats      int
+	Passengers []Voter
+}
+
+// Empty - report whether nobody offers or needs a ride.
+func (c *Carpool) Empty() bool {
+	return len(c.Drivers) == 0 && len(c.Unmatched) == 0
+}
+
+// matchRides - seat confirmed players who need a ride with drivers
+// first come first served, rides of players not going are ignored.
+func matchRides(going []Voter, rides []model.Ride) *Carpool {
+	voters := map[int]Voter{}
+	for _, v := range going {
+		voters[v.ID] = v
+	}
+
+	c := &Carpool{}
+	var passengers []Voter
+	for _, r := range rides {
+		v, ok := voters[r.UserID]
+		if !ok {
+			continue
+		}
+		if r.Driver() {
+			c.Drivers = append(c.Drivers, Driver{Voter: v, Seats: r.Seats})
+			continue
+		}
+		passengers = append(passengers, v)
+	}
+
+	d := 0
+	for _, p := range passengers {
+		for d < len(c.Drivers) && len(c.Drivers[d].Passengers) >= c.Drivers[d].Seats {
+			d++
+		}
+		if d == len(c.Drivers) {
+			c.Unmatched = append(c.Unmatched, p)
+			continue
+		}
+		c.Drivers[d].Passengers = append(c.Drivers[d].Passengers, p)
+	}
+
+	return c
+}
+
+// carpoolCaption - carpool section appended to the vote caption.
+func (b *Bot) carpoolCaption(c *Carpool) string {
+	mk := b.markup()
+	lang := b.channelLanguage()
+
+	names := func(voters []Voter) string {
+		var list []string
+		for _, v := range voters {
+			list = append(list, mk.mention(v.Name, v.ID))
+		}
+		return strings.Join(list, ", ")
+	}
+
+	lines := []string{mk.escape(translate(lang, "carpool.title"))}
+	for _, d := range c.Drivers {
+		line := " " + carSymbol + " " + mk.mention(d.Name, d.ID) + " " + mk.escape(fmt.Sprintf("(%d/%d)", len(d.Passengers), d.Seats))
+		if len(d.Passengers) > 0 {
+			line += ": " + names(d.Passengers)
+		}
+		lines = append(lines, line)
+	}
+	if len(c.Unmatched) > 0 {
+		lines = append(lines, " "+mk.escape(translate(lang, "carpool.unmatched"))+" "+names(c.Unmatched))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (b *Bot) getCarpoolButtons() (*tb.InlineButton, *tb.InlineButton) {
+	lang := b.channelLanguage()
+
+	driveBtn := tb.InlineButton{
+		Unique: fmt.Sprintf("drive_%d", b.Unique),
+		Text:   translate(lang, "btn.drive", b.Config().Carpool.Seats),
+	}
+
+	rideBtn := tb.InlineButton{
+		Unique: fmt.Sprintf("ride_%d", b.Unique),
+		Text:   translate(lang, "btn.ride"),
+	}
+
+	return &driveBtn, &rideBtn
+}
+
+// SetRide - offer `seats` seats of user in the current vote, 0 seats asks for a ride
+// and negative seats withdraw. Only confirmed players take part in carpool.
+func (b *Bot) SetRide(player *model.Player, seats int) error {
+	if b.Pinned == nil {
+		return errors.New("no vote")
+	}
+	if seats > maxSeats {
+		return errors.Errorf("%d seats is more than %d", seats, maxSeats)
+	}
+
+	if seats < 0 {
+		if err := b.Store.DeleteRide(b.getMsgID(), player.UserID); err != nil {
+			return err
+		}
+		return b.UpdateVote()
+	}
+
+	data, err := b.voteData()
+	if err != nil {
+		return err
+	}
+	going := false
+	for _, v := range data.Going.Voters {
+		if v.ID == player.UserID {
+			going = true
+		}
+	}
+	if !going {
+		return errors.Errorf("user %d is not going", player.UserID)
+	}
+
+	if err := b.Store.SaveRide(&model.Ride{VoteID: b.getMsgID(), UserID: player.UserID, PlayerID: player.ID, Seats: seats}); err != nil {
+		return err
+	}
+
+	return b.UpdateVote()
+}
+
+// ride - current ride of user, nil if there is none.
+func (b *Bot) ride(userID int) (*model.Ride, error) {
+	rides, err := b.Store.GetRides(b.getMsgID())
+	if err != nil {
+		return nil, err
+	}
+
+	for i, r := range rides {
+		if r.UserID == userID {
+			return &rides[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// carpoolHandler - toggle offering seats if `drive` is set or asking for a ride otherwise.
+func (b *Bot) carpoolHandler(drive bool) func(*tb.Callback) {
+	return func(c *tb.Callback) {
+		if b.Channel == nil || b.Pinned == nil {
+			b.Respond(c, &tb.CallbackResponse{})
+			return
+		}
+
+		player, err := b.RegisterPlayer(b.Channel.ID, c.Sender)
+		if err != nil {
+			log.Printf("cannot save player: %s", err)
+			b.Respond(c, &tb.CallbackResponse{})
+			return
+		}
+
+		current, err := b.ride(c.Sender.ID)
+		if err != nil {
+			log.Printf("cannot get ride: %s", err)
+			b.Respond(c, &tb.CallbackResponse{})
+			return
+		}
+
+		seats := 0
+		if drive {
+			seats = b.Config().Carpool.Seats
+		}
+		if current != nil && current.Driver() == drive {
+			seats = -1
+		}
+
+		if err := b.SetRide(player, seats); err != nil {
+			log.Printf("cannot set ride: %s", err)
+			b.Respond(c, &tb.CallbackResponse{Text: translate(b.channelLanguage(), "carpool.not_going"), ShowAlert: true})
+			return
+		}
+		b.Respond(c, &tb.CallbackResponse{})
+	}
+}
+
+// notifyDrivers - send drivers their passengers Carpool.NotifyHours before the game, once per vote.
+func (b *Bot) notifyDrivers(now time.Time) error {
+	carpool := b.Config().Carpool
+	hours := carpool.NotifyHours
+	if carpool.Seats == 0 || hours == 0 || b.Pinned == nil {
+		return nil
+	}
+
+	game := b.GameDate()
+	if now.Before(game.Add(-time.Duration(hours)*time.Hour)) || !now.Before(game) {
+		return nil
+	}
+
+	rem, err := b.Store.GetReminderByVoteID(b.getMsgID(), reminderCarpool)
+	if err != nil || rem != nil {
+		return err
+	}
+
+	data, err := b.voteData()
+	if err != nil {
+		return err
+	}
+
+	if _, err := b.Store.CreateReminder(b.getMsgID(), reminderCarpool); err != nil {
+		return err
+	}
+
+	for _, d := range data.Carpool.Drivers {
+		chatID := int64(d.ID)
+		text := b.T(chatID, "carpool.no_passengers")
+		if len(d.Passengers) > 0 {
+			var list []string
+			for _, p := range d.Passengers {
+				name := p.Name
+				if p.Username != "" {
+					name += " (@" + p.Username + ")"
+				}
+				list = append(list, "\n - "+name)
+			}
+			text = b.T(chatID, "carpool.passengers", strings.Join(list, ""))
+		}
+
+		// drivers who never started the bot fail here, that must not stop the others.
+		if _, err := b.Send(&tb.User{ID: d.ID}, text); err != nil {
+			log.Printf("cannot notify driver %d: %s", d.ID, err)
+		}
+	}
+
+	return nil
+}
@@ -1,9 +1,17 @@
 package vote
 
 import (
+	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 )
 
@@ -12,6 +20,7 @@ type Config struct {
 	APIToken string
 	Appeals  []string
 	Place    struct {
+		Name     string
 		URL      string
 		Location struct {
 			Latitude  float64
@@ -23,31 +32,277 @@ type Config struct {
 		ResultFormat string
 		RemindFormat string
 	}
+	// ParseMode - telegram parse mode of formats: Markdown, MarkdownV2 or HTML.
+	ParseMode string
+
 	NoResult string
 	Weekday  int
 	Hour     int
 	Minute   int
-	Admins   []string
-	God      string
+	// Timezone - IANA name of the timezone games are scheduled in.
+	Timezone string
+	// DisplayName - how player names are shown: nickname, first or username.
+	DisplayName string
+	// Limit - maximum number of players, the rest go to waitlist, 0 if unlimited.
+	Limit int
+	// DeadlineHours - how many hours before the kickoff the vote closes.
+	DeadlineHours int
+	// Weather - show forecast for the kickoff time from open-meteo.com.
+	Weather bool
+	// NudgeHours - how many hours before the kickoff regulars who haven't voted are nudged, 0 disables.
+	NudgeHours int
+	// Regulars - players who agreed at least MinGames times in the last Games votes.
+	Regulars struct {
+		Games    int
+		MinGames int
+	}
+	// LateCancel - confirmed players refusing less than Hours before the kickoff
+	// cancel late and are charged Fee to their balance, 0 hours disables.
+	LateCancel struct {
+		Hours int
+		Fee   int
+	}
+	// Carpool - drivers offer Seats seats by default and get their passengers
+	// NotifyHours before the kickoff, 0 seats disables carpool.
+	Carpool struct {
+		Seats       int
+		NotifyHours int
+	}
+	// Duties - equipment confirmed players bring in turn, assigned when the vote closes.
+	Duties []string
+
+	// Owners - telegram user ids with every permission in every chat.
+	Owners []int
+	// Admins - telegram user ids of admins in every chat.
+	Admins []int
+	// InheritChatAdmins - treat telegram admins of a chat as its bot admins.
+	InheritChatAdmins bool
+}
+
+// ConfigError - list of problems found in config.
+type ConfigError []string
+
+func (e ConfigError) Error() string {
+	return "invalid config:\n\t" + strings.Join(e, "\n\t")
+}
+
+// ConfigLoader - reads config file and watches it for changes.
+type ConfigLoader struct {
+	// mu - viper is not safe for concurrent use, the watcher and /reload load at once.
+	mu sync.Mutex
+	v  *viper.Viper
+}
+
+// NewConfigLoader - return loader of `config` file from CONFIG_PATH.
+func NewConfigLoader() *ConfigLoader {
+	v := viper.New()
+	v.SetConfigName("config")
+	v.AddConfigPath(os.Getenv("CONFIG_PATH"))
+
+	return &ConfigLoader{v: v}
 }
 
-// NewConfig - return new config instance
-func NewConfig() *Config {
+// Load - read and validate config file.
+func (l *ConfigLoader) Load() (*Config, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (l *ConfigLoader) read() (*Config, error) {
 	var c Config
 
-	apiToken := os.Getenv("API_TOKEN")
+	if err := l.v.ReadInConfig(); err != nil {
+		return nil, errors.Wrap(err, "cannot read config")
+	}
 
-	viper.SetConfigName("config")
-	viper.AddConfigPath(os.Getenv("CONFIG_PATH"))
-	viper.ReadInConfig()
+	if err := checkLegacyRoles(l.v); err != nil {
+		return nil, err
+	}
+
+	if err := l.v.Unmarshal(&c); err != nil {
+		return nil, errors.Wrap(err, "cannot parse config")
+	}
+
+	c.APIToken = os.Getenv("API_TOKEN")
+
+	return &c, nil
+}
 
-	err := viper.Unmarshal(&c)
+// checkLegacyRoles - report usernames left from configs made before roles
+// were keyed by user ids, they must be replaced with ids by hand.
+func checkLegacyRoles(v *viper.Viper) error {
+	var problems ConfigError
 
+	if v.IsSet("god") {
+		problems = append(problems, "god: usernames are replaced by user ids, put the id of the user into owners")
+	}
+	for i, a := range v.GetStringSlice("admins") {
+		if _, err := strconv.Atoi(a); err != nil {
+			problems = append(problems, fmt.Sprintf("admins[%d]: %q is not a user id, usernames are replaced by user ids", i, a))
+		}
+	}
+
+	if len(problems) > 0 {
+		return problems
+	}
+
+	return nil
+}
+
+// Watch - call `fn` with freshly loaded config on every change of the file.
+func (l *ConfigLoader) Watch(fn func(*Config, error)) {
+	l.v.OnConfigChange(func(e fsnotify.Event) {
+		log.Printf("config changed: %s", e.Name)
+		fn(l.Load())
+	})
+	l.v.WatchConfig()
+}
+
+// Validate - check the whole config and report every problem found:
+// required values, ranges and templates rendered with sample data.
+func (c *Config) Validate() error {
+	var problems ConfigError
+
+	if c.APIToken == "" {
+		problems = append(problems, "API_TOKEN: bot token is required")
+	}
+
+	if len(c.Appeals) == 0 {
+		problems = append(problems, "appeals: at least one appeal is required")
+	}
+	for i, a := range c.Appeals {
+		if strings.TrimSpace(a) == "" {
+			problems = append(problems, fmt.Sprintf("appeals[%d]: must not be empty", i))
+		}
+	}
+
+	ranges := []struct {
+		name     string
+		value    int
+		min, max int
+	}{
+		{"weekday", c.Weekday, 0, 6},
+		{"hour", c.Hour, 0, 23},
+		{"minute", c.Minute, 0, 59},
+		{"limit", c.Limit, 0, 1000},
+		{"deadlineHours", c.DeadlineHours, 0, 7 * 24},
+		{"nudgeHours", c.NudgeHours, 0, 7 * 24},
+		{"regulars.games", c.Regulars.Games, 0, 100},
+		{"regulars.minGames", c.Regulars.MinGames, 0, c.Regulars.Games},
+		{"lateCancel.hours", c.LateCancel.Hours, 0, 7 * 24},
+		{"lateCancel.fee", c.LateCancel.Fee, 0, 1000000},
+		{"carpool.seats", c.Carpool.Seats, 0, maxSeats},
+		{"carpool.notifyHours", c.Carpool.NotifyHours, 0, 7 * 24},
+	}
+	for _, r := range ranges {
+		if r.value < r.min || r.value > r.max {
+			problems = append(problems, fmt.Sprintf("%s: %d is out of range [%d, %d]", r.name, r.value, r.min, r.max))
+		}
+	}
+
+	if c.NudgeHours > 0 && c.Regulars.Games == 0 {
+		problems = append(problems, "regulars.games: required to nudge regulars, set it or disable nudgeHours")
+	}
+
+	if len(c.Duties) > 0 && c.DeadlineHours == 0 {
+		problems = append(problems, "deadlineHours: required to assign duties, set it or remove duties")
+	}
+	seen := map[string]bool{}
+	for i, d := range c.Duties {
+		if strings.TrimSpace(d) == "" || len(d) > 64 {
+			problems = append(problems, fmt.Sprintf("duties[%d]: must be 1 to 64 characters", i))
+		}
+		if seen[d] {
+			problems = append(problems, fmt.Sprintf("duties[%d]: %q is repeated", i, d))
+		}
+		seen[d] = true
+	}
+
+	if c.Timezone != "" {
+		if _, err := loadTimezone(c.Timezone); err != nil {
+			problems = append(problems, fmt.Sprintf("timezone: %s", err))
+		}
+	}
+
+	if c.DisplayName != "" && !Contains(DisplayRules, c.DisplayName) {
+		problems = append(problems, fmt.Sprintf("displayName: %q is not one of %s", c.DisplayName, strings.Join(DisplayRules, ", ")))
+	}
+
+	if len(c.Owners) == 0 && !c.InheritChatAdmins {
+		problems = append(problems, "owners: at least one owner id is required unless inheritChatAdmins is set")
+	}
+
+	if lat := c.Place.Location.Latitude; lat < -90 || lat > 90 {
+		problems = append(problems, fmt.Sprintf("place.location.latitude: %g is out of range [-90, 90]", lat))
+	}
+	if lng := c.Place.Location.Longitude; lng < -180 || lng > 180 {
+		problems = append(problems, fmt.Sprintf("place.location.longitude: %g is out of range [-180, 180]", lng))
+	}
+
+	mode := markup(ModeMarkdown)
+	if c.ParseMode != "" {
+		mode = markup(c.ParseMode)
+		if !Contains(ParseModes, c.ParseMode) {
+			problems = append(problems, fmt.Sprintf("parseMode: %q is not one of %s", c.ParseMode, strings.Join(ParseModes, ", ")))
+		}
+	}
+
+	formats := []struct{ name, format string }{
+		{"formats.voteFormat", c.Formats.VoteFormat},
+		{"formats.resultFormat", c.Formats.ResultFormat},
+		{"formats.remindFormat", c.Formats.RemindFormat},
+	}
+	for _, f := range formats {
+		if err := checkFormat(mode, f.format); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", f.name, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return problems
+	}
+
+	return nil
+}
+
+// checkFormat - parse template and execute it with sample data,
+// referencing a field the bot doesn't pass is an error too.
+func checkFormat(mode markup, format string) error {
+	if strings.TrimSpace(format) == "" {
+		return errors.New("must not be empty")
+	}
+
+	t, err := template.New("").Funcs(templateFuncs(mode, DefaultLanguage)).Parse(format)
 	if err != nil {
-		log.Print(err)
+		return err
+	}
+
+	return t.Execute(ioutil.Discard, sampleVoteData())
+}
+
+// Contains - report whether `list` has `s`.
+func Contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
 	}
 
-	c.APIToken = apiToken
+	return false
+}
 
-	return &c
+// NewConfig - return new validated config.
+func NewConfig() (*Config, error) {
+	return NewConfigLoader().Load()
 }
@@ -0,0 +1,283 @@
+package vote
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/pkg/errors"
+)
+
+// Time - time in the chat timezone, printed as "2006-01-02 15:04".
+// Use the `date` template function for other layouts.
+type Time struct{ time.Time }
+
+func (t Time) String() string {
+	return t.Format("2006-01-02 15:04")
+}
+
+// Voter - player who pressed a button of the vote.
+type Voter struct {
+	// ID - telegram user id.
+	ID int
+	// Name - name by the display rule of the chat, not escaped.
+	Name string
+	// Username - telegram username without @, may be empty.
+	Username string
+	// VotedAt - time the player gave the current answer, voters are listed in this order.
+	VotedAt Time
+	// Proxied - the vote is cast by admin on behalf of the player.
+	Proxied bool
+	// Reason - why the player refused in the chat language, may be empty.
+	Reason string
+}
+
+// Option - button of the vote with its voters.
+type Option struct {
+	// Text - button label.
+	Text string
+	// Symbol - emoji shown before voter names.
+	Symbol string
+	// Count - number of voters.
+	Count int
+	// Voters - voters in order of their votes.
+	Voters []Voter
+}
+
+// Venue - place of the game from config.
+type Venue struct {
+	Name      string
+	URL       string
+	Latitude  float64
+	Longitude float64
+}
+
+// VoteData - data available in voteFormat, resultFormat and remindFormat templates.
+type VoteData struct {
+	// Appeal - random appeal of the vote.
+	Appeal string
+	// Date - kickoff time.
+	Date Time
+	// Deadline - time the vote closes, equals Date if deadlineHours is not set.
+	Deadline Time
+	// Venue - place of the game.
+	Venue Venue
+	// Weather - forecast for the kickoff time, nil if disabled or unknown.
+	Weather *Weather
+
+	// Options - all buttons of the vote in display order.
+	Options []Option
+	// Going - confirmed players, at most Limit of them.
+	Going Option
+	// NotGoing - players who refused.
+	NotGoing Option
+	// Waitlist - players who agreed after the Limit was reached.
+	Waitlist []Voter
+	// Limit - maximum number of confirmed players, 0 if unlimited.
+	Limit int
+	// SpotsLeft - free spots before the Limit, 0 if unlimited.
+	SpotsLeft int
+	// Total - number of players who pressed any button.
+	Total int
+	// Carpool - drivers and passengers among confirmed players, nil if carpool is disabled.
+	Carpool *Carpool
+	// Duties - equipment duties of players, empty until the vote closes.
+	Duties []DutyAssignment
+
+	// Symbols, Yes, No, Agree, Disagree, AgreeNames, DisagreeNames and Users
+	// are kept for formats written before the fields above existed.
+	Symbols       string
+	Yes           string
+	No            string
+	Agree         int
+	Disagree      int
+	AgreeNames    string
+	DisagreeNames string
+	Users         string
+}
+
+// voteData - collect data of the current vote for message templates.
+func (b *Bot) voteData() (*VoteData, error) {
+	settings := b.channelSettings()
+	loc := settings.Location()
+	mk := b.markup()
+	lang := b.channelLanguage()
+	yesBtn, noBtn := b.getButtons()
+
+	game := b.GameDate()
+	cfg := b.Config()
+	place := cfg.Place
+
+	d := &VoteData{
+		Date:     Time{game},
+		Deadline: Time{game.Add(-time.Duration(cfg.DeadlineHours) * time.Hour)},
+		Venue: Venue{
+			Name:      place.Name,
+			URL:       place.URL,
+			Latitude:  place.Location.Latitude,
+			Longitude: place.Location.Longitude,
+		},
+		Going:    Option{Text: yesBtn.Text, Symbol: ballSymbol},
+		NotGoing: Option{Text: noBtn.Text, Symbol: shitSymbol},
+		Limit:    cfg.Limit,
+		Yes:      yesBtn.Text,
+		No:       noBtn.Text,
+	}
+	if b.Vote != nil {
+		d.Appeal = b.Vote.RandAppeal
+	}
+	if cfg.Weather && b.Forecasts != nil {
+		d.Weather = b.Forecasts.Forecast(place.Location.Latitude, place.Location.Longitude, game)
+	}
+
+	var votes []model.Vote
+	if b.Pinned != nil {
+		var err error
+		votes, err = b.Store.GetVotesByVoteID(b.getMsgID())
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(votes, func(i, j int) bool { return votes[i].VotedAt.Before(votes[j].VotedAt) })
+
+	var agreeNames, disagreeNames, waitlistNames, users []string
+	voters := map[int]Voter{}
+	for _, v := range votes {
+		voter := Voter{
+			ID:       v.UserID,
+			Name:     displayName(v.Player, settings.DisplayName),
+			Username: v.Player.Username,
+			VotedAt:  Time{v.VotedAt.In(loc)},
+			Proxied:  v.ProxyBy != 0,
+			Reason:   reasonText(v.Reason, lang),
+		}
+		voters[voter.ID] = voter
+		name := mk.mention(voter.Name, voter.ID)
+		if voter.Proxied {
+			name += " " + proxySymbol
+		}
+
+		if v.PressedBtn != yesBtn.Data {
+			if voter.Reason != "" {
+				name += " — " + mk.escape(voter.Reason)
+			}
+			d.NotGoing.Voters = append(d.NotGoing.Voters, voter)
+			disagreeNames = append(disagreeNames, "\n "+shitSymbol+" "+name)
+			continue
+		}
+
+		if d.Limit > 0 && len(d.Going.Voters) >= d.Limit {
+			d.Waitlist = append(d.Waitlist, voter)
+			waitlistNames = append(waitlistNames, "\n "+waitSymbol+" "+name)
+			continue
+		}
+
+		d.Going.Voters = append(d.Going.Voters, voter)
+		agreeNames = append(agreeNames, "\n "+ballSymbol+" "+name)
+		users = append(users, mk.mention(voter.Name, voter.ID))
+	}
+
+	if b.Config().Carpool.Seats > 0 && b.Pinned != nil {
+		rides, err := b.Store.GetRides(b.getMsgID())
+		if err != nil {
+			return nil, err
+		}
+		d.Carpool = matchRides(d.Going.Voters, rides)
+	}
+	if len(b.Config().Duties) > 0 && b.Pinned != nil && b.Channel != nil {
+		var err error
+		if d.Duties, err = b.voteDuties(voters); err != nil {
+			return nil, err
+		}
+	}
+
+	d.Going.Count = len(d.Going.Voters)
+	d.NotGoing.Count = len(d.NotGoing.Voters)
+	d.Options = []Option{d.Going, d.NotGoing}
+	d.Total = len(votes)
+	if d.Limit > d.Going.Count {
+		d.SpotsLeft = d.Limit - d.Going.Count
+	}
+
+	d.Symbols = strings.Repeat(userSymbol, d.Going.Count)
+	d.Agree = d.Going.Count
+	d.Disagree = d.NotGoing.Count
+	// the legacy list shows the waitlist too, formats don't render .Waitlist.
+	if len(waitlistNames) > 0 {
+		agreeNames = append(agreeNames, "\n"+mk.escape(translate(lang, "waitlist.title")))
+		agreeNames = append(agreeNames, waitlistNames...)
+	}
+	d.AgreeNames = strings.Join(agreeNames, "")
+	d.DisagreeNames = strings.Join(disagreeNames, "")
+	d.Users = strings.Join(users, " ")
+
+	return d, nil
+}
+
+// sampleVoteData - vote data used to check and preview formats.
+func sampleVoteData() *VoteData {
+	game := time.Date(2006, time.January, 2, 19, 0, 0, 0, time.UTC)
+	going := []Voter{
+		{ID: 1, Name: "First Last", Username: "first", VotedAt: Time{game.Add(-48 * time.Hour)}},
+		{ID: 2, Name: "Second", VotedAt: Time{game.Add(-47 * time.Hour)}},
+	}
+	notGoing := []Voter{
+		{ID: 3, Name: "Third", Username: "third", VotedAt: Time{game.Add(-46 * time.Hour)}, Reason: "work"},
+	}
+
+	d := &VoteData{
+		Appeal:        "appeal",
+		Date:          Time{game},
+		Deadline:      Time{game.Add(-2 * time.Hour)},
+		Venue:         Venue{Name: "Venue", URL: "http://example.com"},
+		Weather:       &Weather{Temperature: 12.5, Precipitation: 10, Summary: "☁️"},
+		Going:         Option{Text: "yes", Symbol: ballSymbol, Count: len(going), Voters: going},
+		NotGoing:      Option{Text: "no", Symbol: shitSymbol, Count: len(notGoing), Voters: notGoing},
+		Waitlist:      []Voter{{ID: 4, Name: "Fourth", VotedAt: Time{game.Add(-24 * time.Hour)}}},
+		Limit:         2,
+		Total:         4,
+		Symbols:       userSymbol + userSymbol,
+		Yes:           "yes",
+		No:            "no",
+		Agree:         len(going),
+		Disagree:      len(notGoing),
+		AgreeNames:    "\n " + ballSymbol + " [First Last](tg://user?id=1)\n " + ballSymbol + " [Second](tg://user?id=2)\n" + translate(DefaultLanguage, "waitlist.title") + "\n " + waitSymbol + " [Fourth](tg://user?id=4)",
+		DisagreeNames: "\n " + shitSymbol + " [Third](tg://user?id=3) — work",
+		Users:         "[First Last](tg://user?id=1) [Second](tg://user?id=2)",
+	}
+	d.Options = []Option{d.Going, d.NotGoing}
+	d.Carpool = &Carpool{Drivers: []Driver{{Voter: going[0], Seats: 3, Passengers: going[1:]}}}
+	d.Duties = []DutyAssignment{{Name: "ball", Voter: going[1]}}
+
+	return d
+}
+
+// PreviewKinds - formats that can be previewed.
+var PreviewKinds = []string{"vote", "result", "remind"}
+
+// Preview - render format of `kind` with data of the current vote,
+// or with sample data if `sample` is set or there is no vote.
+func (b *Bot) Preview(kind string, sample bool) (string, error) {
+	cfg := b.Config()
+	formats := map[string]string{
+		"vote":   cfg.Formats.VoteFormat,
+		"result": cfg.Formats.ResultFormat,
+		"remind": cfg.Formats.RemindFormat,
+	}
+
+	format, ok := formats[kind]
+	if !ok {
+		return "", errors.Errorf("unknown format %q", kind)
+	}
+
+	data := sampleVoteData()
+	if !sample && b.Pinned != nil {
+		var err error
+		if data, err = b.voteData(); err != nil {
+			return "", err
+		}
+	}
+
+	return b.render(format, data)
+}
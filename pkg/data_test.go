@@ -0,0 +1,38 @@
+package vote
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitlistOrder(t *testing.T) {
+	b, tg := newTestBot(t, func(c *Config) { c.Limit = 1 })
+	b.Tick(time.Now())
+
+	check := func(going, waiting int) {
+		t.Helper()
+		d, err := b.voteData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(d.Going.Voters) != 1 || d.Going.Voters[0].ID != going {
+			t.Errorf("going %+v, want user %d", d.Going.Voters, going)
+		}
+		if len(d.Waitlist) != 1 || d.Waitlist[0].ID != waiting {
+			t.Errorf("waitlist %+v, want user %d", d.Waitlist, waiting)
+		}
+	}
+
+	press(t, b, tg, "yes", testMax)
+	press(t, b, tg, "yes", testBob)
+	check(testMax.ID, testBob.ID)
+
+	// pressing the same button again keeps the place.
+	press(t, b, tg, "yes", testMax)
+	check(testMax.ID, testBob.ID)
+
+	// changing the answer back and forth moves to the end of the queue.
+	press(t, b, tg, "no", testMax)
+	press(t, b, tg, "yes", testMax)
+	check(testBob.ID, testMax.ID)
+}
@@ -0,0 +1,358 @@
+package vote
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const dutySymbol = "🎒"
+
+// reminderDuties - kind of the reminder recorded when duties are assigned.
+const reminderDuties = "duties"
+
+// DutyAssignment - duty of the current vote with the player who has it.
+type DutyAssignment struct {
+	Name  string
+	Voter Voter
+}
+
+// PlayerDuties - how many times player had every duty.
+type PlayerDuties struct {
+	ID     int
+	Name   string
+	Duties map[string]int
+}
+
+// dutyOrder - confirmed players but `exclude` in order they should take duty `name`:
+// who had it fewer times, then fewer duties at all, then longer ago, then who voted first.
+func dutyOrder(name string, going []Voter, history []model.Duty, exclude map[int]bool) []Voter {
+	same, total := map[int]int{}, map[int]int{}
+	last := map[int]uint{}
+	for _, d := range history {
+		if d.Name == name {
+			same[d.UserID]++
+		}
+		total[d.UserID]++
+		if d.ID > last[d.UserID] {
+			last[d.UserID] = d.ID
+		}
+	}
+
+	var list []Voter
+	for _, v := range going {
+		if !exclude[v.ID] {
+			list = append(list, v)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i].ID, list[j].ID
+		if same[a] != same[b] {
+			return same[a] < same[b]
+		}
+		if total[a] != total[b] {
+			return total[a] < total[b]
+		}
+		return last[a] < last[b]
+	})
+
+	return list
+}
+
+// dutyHistory - duties of chat assigned before the current vote.
+func (b *Bot) dutyHistory() ([]model.Duty, error) {
+	duties, err := b.Store.GetDuties(b.Channel.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []model.Duty
+	for _, d := range duties {
+		if d.VoteID != b.getMsgID() {
+			history = append(history, d)
+		}
+	}
+
+	return history, nil
+}
+
+// assignDuties - hand out duties among confirmed players in fair rotation
+// when the vote closes, once per vote.
+func (b *Bot) assignDuties(now time.Time) error {
+	cfg := b.Config()
+	if len(cfg.Duties) == 0 || b.Pinned == nil || b.Channel == nil {
+		return nil
+	}
+
+	game := b.GameDate()
+	if now.Before(game.Add(-time.Duration(cfg.DeadlineHours)*time.Hour)) || !now.Before(game) {
+		return nil
+	}
+
+	rem, err := b.Store.GetReminderByVoteID(b.getMsgID(), reminderDuties)
+	if err != nil || rem != nil {
+		return err
+	}
+
+	data, err := b.voteData()
+	if err != nil {
+		return err
+	}
+	history, err := b.dutyHistory()
+	if err != nil {
+		return err
+	}
+
+	// nobody to assign yet, try again on the next tick.
+	if len(data.Going.Voters) == 0 {
+		return nil
+	}
+	if _, err := b.Store.CreateReminder(b.getMsgID(), reminderDuties); err != nil {
+		return err
+	}
+
+	assigned := map[int]bool{}
+	for _, name := range cfg.Duties {
+		candidates := dutyOrder(name, data.Going.Voters, history, assigned)
+		if len(candidates) == 0 {
+			// fewer players than duties, everyone gets one more.
+			assigned = map[int]bool{}
+			candidates = dutyOrder(name, data.Going.Voters, history, assigned)
+		}
+
+		duty, err := b.giveDuty(&model.Duty{Name: name}, candidates[0].ID)
+		if err != nil {
+			return err
+		}
+		assigned[duty.UserID] = true
+		history = append(history, *duty)
+	}
+
+	return b.UpdateVote()
+}
+
+// giveDuty - save duty of the current vote to user.
+func (b *Bot) giveDuty(d *model.Duty, userID int) (*model.Duty, error) {
+	p, err := b.Store.GetPlayer(b.Channel.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	d.ChatID, d.VoteID, d.UserID, d.PlayerID = b.Channel.ID, b.getMsgID(), userID, 0
+	if p != nil {
+		d.PlayerID = p.ID
+	}
+	if err := b.Store.SaveDuty(d); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// PassDuty - pass duties of user in the current vote to the next players in rotation
+// who have no duty yet and haven't passed it, and update the pinned message.
+// Duties of user who is not going go back to confirmed players who passed them
+// if nobody else can take them, otherwise duties nobody can take stay with the user.
+func (b *Bot) PassDuty(userID int) error {
+	if b.Pinned == nil || b.Channel == nil {
+		return errors.New("no vote")
+	}
+
+	duties, err := b.Store.GetVoteDuties(b.getMsgID())
+	if err != nil {
+		return err
+	}
+	data, err := b.voteData()
+	if err != nil {
+		return err
+	}
+	history, err := b.dutyHistory()
+	if err != nil {
+		return err
+	}
+
+	going := false
+	for _, v := range data.Going.Voters {
+		if v.ID == userID {
+			going = true
+		}
+	}
+
+	held, moved := false, false
+	for i := range duties {
+		d := &duties[i]
+		if d.UserID != userID {
+			continue
+		}
+		held = true
+
+		passed := map[int]bool{userID: true}
+		for _, id := range strings.Split(d.Passed, ",") {
+			if n, err := strconv.Atoi(id); err == nil {
+				passed[n] = true
+			}
+		}
+		busy := map[int]bool{}
+		for id := range passed {
+			busy[id] = true
+		}
+		for _, other := range duties {
+			busy[other.UserID] = true
+		}
+
+		candidates := dutyOrder(d.Name, data.Going.Voters, history, busy)
+		if len(candidates) == 0 {
+			// who is not going can't bring it, any confirmed player is better.
+			exclude := passed
+			if !going {
+				exclude = nil
+			}
+			candidates = dutyOrder(d.Name, data.Going.Voters, history, exclude)
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		if d.Passed != "" {
+			d.Passed += ","
+		}
+		d.Passed += strconv.Itoa(userID)
+		if _, err := b.giveDuty(d, candidates[0].ID); err != nil {
+			return err
+		}
+		moved = true
+	}
+	if !held {
+		return errors.Errorf("user %d has no duty", userID)
+	}
+	if !moved {
+		return errors.Errorf("nobody to take duties of user %d", userID)
+	}
+
+	return b.UpdateVote()
+}
+
+// releaseDuties - pass duties of player who is not going anymore.
+func (b *Bot) releaseDuties(userID int) {
+	if len(b.Config().Duties) == 0 {
+		return
+	}
+
+	duties, err := b.Store.GetVoteDuties(b.getMsgID())
+	if err != nil {
+		log.Printf("cannot get duties: %s", err)
+		return
+	}
+	for _, d := range duties {
+		if d.UserID == userID {
+			if err := b.PassDuty(userID); err != nil {
+				log.Printf("cannot pass duty: %s", err)
+			}
+			return
+		}
+	}
+}
+
+// voteDuties - duties of the current vote with their players from `voters` by user id.
+func (b *Bot) voteDuties(voters map[int]Voter) ([]DutyAssignment, error) {
+	duties, err := b.Store.GetVoteDuties(b.getMsgID())
+	if err != nil {
+		return nil, err
+	}
+
+	var list []DutyAssignment
+	for _, d := range duties {
+		voter, ok := voters[d.UserID]
+		if !ok {
+			// vote of the player is removed and nobody took the duty.
+			p, err := b.Store.GetPlayer(b.Channel.ID, d.UserID)
+			if err != nil {
+				return nil, err
+			}
+			if p == nil {
+				p = &model.Player{UserID: d.UserID}
+			}
+			voter = Voter{ID: d.UserID, Name: displayName(*p, b.channelSettings().DisplayName), Username: p.Username}
+		}
+		list = append(list, DutyAssignment{Name: d.Name, Voter: voter})
+	}
+
+	return list, nil
+}
+
+// dutiesCaption - duties section appended to the vote caption.
+func (b *Bot) dutiesCaption(duties []DutyAssignment) string {
+	mk := b.markup()
+
+	lines := []string{mk.escape(translate(b.channelLanguage(), "duty.title"))}
+	for _, d := range duties {
+		lines = append(lines, fmt.Sprintf(" %s %s — %s", dutySymbol, mk.escape(d.Name), mk.mention(d.Voter.Name, d.Voter.ID)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (b *Bot) getDutyButton() *tb.InlineButton {
+	return &tb.InlineButton{
+		Unique: fmt.Sprintf("duty_%d", b.Unique),
+		Text:   translate(b.channelLanguage(), "btn.duty"),
+	}
+}
+
+func (b *Bot) dutyHandler(c *tb.Callback) {
+	if err := b.PassDuty(c.Sender.ID); err != nil {
+		log.Printf("cannot pass duty: %s", err)
+		b.Respond(c, &tb.CallbackResponse{Text: translate(b.channelLanguage(), "duty.cannot_pass"), ShowAlert: true})
+		return
+	}
+
+	b.Respond(c, &tb.CallbackResponse{})
+}
+
+// DutyHistory - how many times every player of chat had every duty, most duties first.
+func (b *Bot) DutyHistory(chatID int64) ([]PlayerDuties, error) {
+	settings, err := b.Settings(chatID)
+	if err != nil {
+		return nil, err
+	}
+	duties, err := b.Store.GetDuties(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := map[int]*PlayerDuties{}
+	total := map[int]int{}
+	var list []*PlayerDuties
+	for _, d := range duties {
+		p, ok := byUser[d.UserID]
+		if !ok {
+			player, err := b.Store.GetPlayer(chatID, d.UserID)
+			if err != nil {
+				return nil, err
+			}
+			if player == nil {
+				player = &model.Player{UserID: d.UserID}
+			}
+			p = &PlayerDuties{ID: d.UserID, Name: displayName(*player, settings.DisplayName), Duties: map[string]int{}}
+			byUser[d.UserID] = p
+			list = append(list, p)
+		}
+		p.Duties[d.Name]++
+		total[d.UserID]++
+	}
+	sort.SliceStable(list, func(i, j int) bool { return total[list[i].ID] > total[list[j].ID] })
+
+	history := make([]PlayerDuties, 0, len(list))
+	for _, p := range list {
+		history = append(history, *p)
+	}
+
+	return history, nil
+}
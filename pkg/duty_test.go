@@ -0,0 +1,68 @@
+package vote
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDuties(t *testing.T) {
+	// the vote closes right after it is created.
+	b, tg := newTestBot(t, func(c *Config) {
+		c.Duties = []string{"ball", "bibs"}
+		c.DeadlineHours = 8 * 24
+	})
+	b.Tick(time.Now())
+
+	press(t, b, tg, "yes", testMax)
+	press(t, b, tg, "yes", testBob)
+	b.Tick(time.Now())
+
+	duties, err := b.Store.GetVoteDuties(b.getMsgID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(duties) != 2 || duties[0].UserID == duties[1].UserID {
+		t.Fatalf("duties are not shared: %+v", duties)
+	}
+	if text := pinned(t, tg).Text; strings.Count(text, dutySymbol) != 2 {
+		t.Errorf("vote has no duties: %q", text)
+	}
+
+	// duties are assigned once per vote.
+	b.Tick(time.Now())
+	if again, err := b.Store.GetVoteDuties(b.getMsgID()); err != nil || len(again) != 2 {
+		t.Fatalf("duties are assigned again: %+v (%v)", again, err)
+	}
+
+	holders := func() map[string]int {
+		t.Helper()
+		duties, err := b.Store.GetVoteDuties(b.getMsgID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		list := map[string]int{}
+		for _, d := range duties {
+			list[d.Name] = d.UserID
+		}
+		return list
+	}
+
+	// the duty button passes the duty to the only other player.
+	if err := tg.Press(pinned(t, tg).Message, fmt.Sprintf("duty_%d", b.Unique), testMax); err != nil {
+		t.Fatal(err)
+	}
+	if got := holders(); got["ball"] != testBob.ID || got["bibs"] != testBob.ID {
+		t.Fatalf("duty is not passed: %v", got)
+	}
+
+	// duties of refused player go back to who is still going even if they passed them.
+	press(t, b, tg, "no", testBob)
+	if got := holders(); got["ball"] != testMax.ID || got["bibs"] != testMax.ID {
+		t.Errorf("duties are left with the refused player: %v", got)
+	}
+	if text := pinned(t, tg).Text; strings.Count(text, dutySymbol) != 2 {
+		t.Errorf("vote has no duties: %q", text)
+	}
+}
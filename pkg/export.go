@@ -0,0 +1,200 @@
+package vote
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Export formats.
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+)
+
+// ExportFormats - all export formats.
+var ExportFormats = []string{ExportCSV, ExportJSON}
+
+// ExportedGame - game of chat with its votes.
+type ExportedGame struct {
+	VoteID int            `json:"vote_id"`
+	Date   time.Time      `json:"date"`
+	Votes  []ExportedVote `json:"votes"`
+}
+
+// ExportedVote - vote of player in game.
+type ExportedVote struct {
+	UserID   int       `json:"user_id"`
+	Username string    `json:"username"`
+	Name     string    `json:"name"`
+	Option   string    `json:"option"`
+	VotedAt  time.Time `json:"voted_at"`
+	// Reason - why the player refused, a preset key or free text.
+	Reason string `json:"reason,omitempty"`
+}
+
+// saveGame - record game of the current vote in the vote channel.
+func (b *Bot) saveGame() error {
+	if b.Channel == nil || b.Pinned == nil {
+		return nil
+	}
+
+	return b.Store.SaveGame(&model.Game{ChatID: b.Channel.ID, VoteID: b.getMsgID(), Date: b.GameDate()})
+}
+
+// saveSpecialGame - record game of the current vote played at `date` off the chat schedule.
+func (b *Bot) saveSpecialGame(date time.Time) error {
+	if b.Channel == nil || b.Pinned == nil {
+		return nil
+	}
+
+	g := &model.Game{ChatID: b.Channel.ID, VoteID: b.getMsgID(), Date: date, Special: true}
+	b.mu.Lock()
+	b.gameDates[g.VoteID] = date
+	b.mu.Unlock()
+
+	return b.Store.SaveGame(g)
+}
+
+// chatGames - games of chat played in [from, to) ordered by date, the vote channel
+// also gets games stored before chats were, the bot ran in a single channel then.
+func (b *Bot) chatGames(chatID int64, from, to time.Time) ([]model.Game, error) {
+	games, err := b.Store.GetGames(chatID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if b.Channel != nil && b.Channel.ID == chatID {
+		legacy, err := b.Store.GetGames(0, from, to)
+		if err != nil {
+			return nil, err
+		}
+		games = append(legacy, games...)
+	}
+	sort.SliceStable(games, func(i, j int) bool { return games[i].Date.Before(games[j].Date) })
+
+	return games, nil
+}
+
+// Games - return games of chat played in [from, to) with their votes.
+// Games recorded before chats were stored have no chat, they were played
+// in the vote channel and are included only for it.
+func (b *Bot) Games(chatID int64, from, to time.Time) ([]ExportedGame, error) {
+	games, err := b.chatGames(chatID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := b.Settings(chatID)
+	if err != nil {
+		return nil, err
+	}
+	loc := settings.Location()
+	yesBtn, _ := b.getButtons()
+
+	exported := make([]ExportedGame, 0, len(games))
+	for _, g := range games {
+		votes, err := b.Store.GetVotesByVoteID(g.VoteID)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(votes, func(i, j int) bool { return votes[i].VotedAt.Before(votes[j].VotedAt) })
+
+		eg := ExportedGame{VoteID: g.VoteID, Date: g.Date.In(loc), Votes: []ExportedVote{}}
+		for _, v := range votes {
+			option := "no"
+			if v.PressedBtn == yesBtn.Data {
+				option = "yes"
+			}
+
+			eg.Votes = append(eg.Votes, ExportedVote{
+				UserID:   v.UserID,
+				Username: v.Player.Username,
+				Name:     displayName(v.Player, settings.DisplayName),
+				Option:   option,
+				VotedAt:  v.VotedAt.In(loc),
+				Reason:   v.Reason,
+			})
+		}
+		exported = append(exported, eg)
+	}
+
+	return exported, nil
+}
+
+// Export - write games of chat played in [from, to) with their votes to `w` in `format`.
+// CSV has a row per vote, JSON has a list of games with their votes.
+func (b *Bot) Export(w io.Writer, chatID int64, format string, from, to time.Time) error {
+	games, err := b.Games(chatID, from, to)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case ExportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(games)
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"game_date", "vote_id", "user_id", "username", "name", "option", "voted_at", "reason"})
+		for _, g := range games {
+			for _, v := range g.Votes {
+				cw.Write([]string{
+					g.Date.Format(time.RFC3339),
+					strconv.Itoa(g.VoteID),
+					strconv.Itoa(v.UserID),
+					v.Username,
+					v.Name,
+					v.Option,
+					v.VotedAt.Format(time.RFC3339),
+					v.Reason,
+				})
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	return errors.Errorf("unknown export format %q", format)
+}
+
+// SendExport - send export of chat games played in [from, to) as a file to recipient.
+func (b *Bot) SendExport(to tb.Recipient, chatID int64, format string, from, till time.Time) error {
+	dir, err := ioutil.TempDir("", "vote-export")
+	if err != nil {
+		return errors.Wrap(err, "cannot create export dir")
+	}
+	defer os.RemoveAll(dir)
+
+	// telegram takes the document name from the uploaded file name.
+	name := filepath.Join(dir, "votes."+format)
+	f, err := os.Create(name)
+	if err != nil {
+		return errors.Wrap(err, "cannot create export file")
+	}
+
+	err = b.Export(f, chatID, format, from, till)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrap(err, "cannot export votes")
+	}
+
+	doc := &tb.Document{File: tb.FromDisk(name), FileName: filepath.Base(name)}
+	if _, err := b.Send(to, doc); err != nil {
+		return errors.Wrap(err, "cannot send export")
+	}
+
+	return nil
+}
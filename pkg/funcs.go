@@ -0,0 +1,143 @@
+package vote
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var dateNames = map[string]*strings.Replacer{
+	"ru": strings.NewReplacer(
+		"January", "января", "February", "февраля", "March", "марта", "April", "апреля",
+		"May", "мая", "June", "июня", "July", "июля", "August", "августа",
+		"September", "сентября", "October", "октября", "November", "ноября", "December", "декабря",
+		"Jan", "янв", "Feb", "фев", "Mar", "мар", "Apr", "апр", "Jun", "июн", "Jul", "июл",
+		"Aug", "авг", "Sep", "сен", "Oct", "окт", "Nov", "ноя", "Dec", "дек",
+		"Monday", "понедельник", "Tuesday", "вторник", "Wednesday", "среда", "Thursday", "четверг",
+		"Friday", "пятница", "Saturday", "суббота", "Sunday", "воскресенье",
+		"Mon", "пн", "Tue", "вт", "Wed", "ср", "Thu", "чт", "Fri", "пт", "Sat", "сб", "Sun", "вс",
+	),
+}
+
+// templateFuncs - functions available in message templates:
+//
+//	escape s           - escape text for the parse mode.
+//	mention voter      - link to Voter, or `mention name id`.
+//	mentions voters    - links to every voter.
+//	names voters       - escaped names of every voter.
+//	                     Both mark votes cast by admin with ✍️.
+//	join list sep      - join list of strings.
+//	date t layout      - format time with Go layout, names of months and
+//	                     weekdays are in the chat language.
+//	plural n forms...  - word form for n: `plural n "игрок" "игрока" "игроков"`
+//	                     or `plural n "player" "players"`.
+//	add a b            - sum of numbers.
+func templateFuncs(mk markup, lang string) template.FuncMap {
+	return template.FuncMap{
+		"escape": mk.escape,
+		"mention": func(v interface{}, id ...int) (string, error) {
+			switch v := v.(type) {
+			case Voter:
+				return mk.mention(v.Name, v.ID), nil
+			case string:
+				if len(id) != 1 {
+					return "", fmt.Errorf("mention of name requires user id")
+				}
+				return mk.mention(v, id[0]), nil
+			}
+			return "", fmt.Errorf("cannot mention %T", v)
+		},
+		"mentions": func(voters []Voter) []string {
+			var list []string
+			for _, v := range voters {
+				list = append(list, proxied(v, mk.mention(v.Name, v.ID)))
+			}
+			return list
+		},
+		"names": func(voters []Voter) []string {
+			var list []string
+			for _, v := range voters {
+				list = append(list, proxied(v, mk.escape(v.Name)))
+			}
+			return list
+		},
+		"join": func(list []string, sep string) string {
+			return strings.Join(list, sep)
+		},
+		"date": func(t Time, layout string) string {
+			return formatDate(t.Time, layout, lang)
+		},
+		"plural": plural,
+		"add": func(a, b int) int {
+			return a + b
+		},
+	}
+}
+
+// proxied - append proxy mark to `s` if vote of voter is cast by admin.
+func proxied(v Voter, s string) string {
+	if v.Proxied {
+		return s + " " + proxySymbol
+	}
+
+	return s
+}
+
+// formatDate - format time with month and weekday names in language `lang`.
+func formatDate(t time.Time, layout, lang string) string {
+	s := t.Format(layout)
+	if r, ok := dateNames[lang]; ok {
+		s = r.Replace(s)
+	}
+
+	return s
+}
+
+// plural - choose word form for n, three forms follow slavic rules
+// (one, few, many), two forms follow english ones (one, other).
+func plural(n int, forms ...string) (string, error) {
+	if n < 0 {
+		n = -n
+	}
+
+	switch len(forms) {
+	case 2:
+		if n == 1 {
+			return forms[0], nil
+		}
+		return forms[1], nil
+	case 3:
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return forms[0], nil
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20):
+			return forms[1], nil
+		default:
+			return forms[2], nil
+		}
+	}
+
+	return "", fmt.Errorf("plural requires 2 or 3 forms, got %d", len(forms))
+}
+
+// render - execute message template with functions of parse mode and language.
+func render(mk markup, lang, format string, data interface{}) (string, error) {
+	t, err := template.New("").Funcs(templateFuncs(mk, lang)).Parse(format)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.Buffer{}
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// render - execute message template for the vote channel.
+func (b *Bot) render(format string, data interface{}) (string, error) {
+	return render(b.markup(), b.channelLanguage(), format, data)
+}
@@ -0,0 +1,354 @@
+package vote
+
+import (
+	"fmt"
+	"log"
+	"sort"
+)
+
+// DefaultLanguage - language of chats without explicit setting.
+const DefaultLanguage = "ru"
+
+// Catalog - user-facing messages of one language by key.
+type Catalog map[string]string
+
+var catalogs = map[string]Catalog{
+	"ru": {
+		"btn.yes":                   "Да",
+		"btn.no":                    "Нет",
+		"permission_denied":         "Permission denied, Пёс",
+		"ok":                        "👌",
+		"set_date.format":           "Лоховской формат: %s, нада типо Sunday 19:00",
+		"lang.usage":                "Доступные языки: %s",
+		"lang.changed":              "Теперь говорим по-русски",
+		"reload.ok":                 "Конфиг перечитан 👌",
+		"reload.failed":             "Конфиг не применён:\n%s",
+		"no_channel":                "Бот ещё не запущен в группе",
+		"settings.title":            "Настройки:",
+		"settings.line":             "%s: %s (%s)",
+		"source.default":            "по умолчанию",
+		"source.config":             "config.json",
+		"source.chat":               "изменено в чате",
+		"roles.add_usage":           "Нада типо /admin_add @username [%s] или ответом на сообщение: %s",
+		"roles.remove_usage":        "Нада типо /admin_remove @username или ответом на сообщение: %s",
+		"roles.added":               "%s теперь %s",
+		"roles.removed":             "%s больше без роли",
+		"roles.title":               "Роли:",
+		"roles.line":                "%s - %s",
+		"roles.inherited":           "+ админы группы",
+		"role.owner":                "владелец",
+		"role.admin":                "админ",
+		"role.treasurer":            "казначей",
+		"role.player":               "игрок",
+		"timezone.usage":            "Нада типо /timezone Europe/Kiev: %s",
+		"preview.usage":             "Нада типо /preview %s [sample]",
+		"preview.failed":            "Шаблон сломан: %s",
+		"inline.title":              "⚽️ Игра: %s",
+		"inline.going":              "идут: %d",
+		"inline.spots":              "свободно мест: %d",
+		"inline.button":             "Голосовать",
+		"no_vote":                   "Сейчас нет голосования",
+		"notify.subscribed":         "Буду присылать напоминания лично. Настроить: /notify, тихие часы: /quiet 23-8, отписаться: /stop",
+		"notify.unsubscribed":       "Больше не пишу лично",
+		"notify.not_subscribed":     "Ты не подписан, нажми /start",
+		"notify.title":              "Личные напоминания:",
+		"notify.quiet":              "Тихие часы: %02d:00-%02d:00",
+		"notify.quiet_off":          "Тихие часы: выкл",
+		"notify.quiet_usage":        "Нада типо /quiet 23-8 или /quiet off: %s",
+		"notify.kind.vote_open":     "Голосование открыто",
+		"notify.kind.game_tomorrow": "Игра завтра",
+		"notify.kind.promoted":      "Попал в основу из запаса",
+		"notify.vote_open":          "Голосование открыто, игра %s",
+		"notify.game_tomorrow":      "Завтра игра: %s",
+		"notify.promoted":           "Освободилось место, ты в основе на %s!",
+		"notify.kind.nudge":         "Не проголосовал",
+		"notify.nudge":              "Ты ещё не проголосовал за игру %s",
+		"nudge.group":               "%s, вы ещё не проголосовали 👀",
+		"nick.usage":                "Нада типо /nick Имя, админ может /nick @user Имя: %s",
+		"display.usage":             "Нада типо /display %s: %s",
+		"export.usage":              "Нада типо /export [%s] [2024-09-01] [2025-06-01]: %s",
+		"export.failed":             "Не удалось выгрузить: %s",
+		"import.done":               "Импорт: строк %d, игр %d, голосов %d, пропущено %d",
+		"import.dry_run":            "Проверка импорта: строк %d, игр %d, голосов %d, пропущено %d",
+		"import.more":               "...и ещё %d",
+		"import.failed":             "Импорт не удался: %s",
+		"add.usage":                 "Нада типо /add @user да, /add Имя Фамилия нет или ответом на сообщение: %s",
+		"remove.usage":              "Нада типо /remove @user или /remove Имя Фамилия: %s",
+		"audit.title":               "Изменения голосования:",
+		"audit.line":                "%s %s: %s",
+		"audit.by":                  "(за него %s)",
+		"audit.yes":                 "да",
+		"audit.no":                  "нет",
+		"audit.removed":             "голос удалён",
+		"audit.empty":               "Пока никто не голосовал",
+		"reason.ask":                "Жаль! Шо случилось? Выбери или напиши в ответ пару слов",
+		"reason.injury":             "травма",
+		"reason.travel":             "в отъезде",
+		"reason.work":               "работа",
+		"reason.saved":              "Понял, причина: %s",
+		"reason.usage":              "Нада типо /reason работа после голоса «нет»: %s",
+		"stats.usage":               "Нада типо /stats [с] [по], даты 2006-01-02: %s",
+		"stats.title":               "Игр: %d",
+		"stats.player":              "%s — да %d, нет %d",
+		"stats.reasons":             "Причины: %s",
+		"stats.late":                "поздних отказов %d",
+		"stats.late_total":          "Поздних отказов: %d",
+		"balance.own":               "Твой баланс: %d",
+		"balance.line":              "%s: %d",
+		"balance.usage":             "Нада типо /balance @user 500 или /balance @user -200: %s",
+		"btn.drive":                 "🚗 Подвезу (мест: %d)",
+		"btn.ride":                  "🙋 Подвезите",
+		"carpool.title":             "Кто кого везёт:",
+		"carpool.unmatched":         "🙋 ищут машину:",
+		"carpool.not_going":         "Сначала нажми «Да»",
+		"carpool.usage":             "Нада типо /drive 3, /drive off, /ride или /ride off, только если идёшь: %s",
+		"carpool.disabled":          "Тут не подвозят",
+		"carpool.passengers":        "Ты везёшь на игру:%s",
+		"carpool.no_passengers":     "Пока никто не просился к тебе в машину",
+		"btn.duty":                  "🔄 Передать дежурство",
+		"duty.title":                "Кто что несёт:",
+		"duty.cannot_pass":          "У тебя нет дежурства или некому его передать",
+		"duty.history":              "Кто сколько раз дежурил:",
+		"duty.empty":                "Дежурств ещё не было",
+		"poll.title":                "Когда играем? Жми все слоты, в которые сможешь:",
+		"poll.results":              "Опрос закрыт, итоги:",
+		"poll.marked":               "Отметил",
+		"poll.unmarked":             "Снял отметку",
+		"poll.closed":               "Опрос уже закрыт",
+		"poll.usage":                "Нада типо /poll 2024-06-01 18:00; 02.06 19:30, от 2 до 8 слотов в будущем: %s",
+		"poll.no_poll":              "Нет открытого опроса",
+		"poll.promoted":             "Голосование создано",
+		"poll.cannot_promote":       "Не получилось назначить игру на этот слот",
+		"btn.poll_promote":          "Играем %s",
+		"waitlist.title":            "Лист ожидания:",
+		"chat_not_found":            "Чат %s не найден",
+		"help": `
+	/help                   - показать эту справку.
+	/start                  - запустить бота.
+	/where                  - где играем.
+	/info                   - информация о поле.
+	/result                 - результат последнего голосования.
+	/lang                   - сменить язык чата.
+	/reload                 - перечитать конфиг.
+	/settings               - текущие настройки.
+	/admins                 - список ролей.
+	/admin_add @user [role] - выдать роль.
+	/admin_remove @user     - забрать роль.
+	/timezone Europe/Kiev   - часовой пояс игр.
+	/preview vote [sample]  - показать шаблон лично.
+	/notify                 - личные напоминания.
+	/quiet 23-8             - тихие часы напоминаний.
+	/stop                   - отписаться от напоминаний.
+	/nick Имя               - никнейм в чате, пустой сбрасывает.
+	/display nickname       - как показывать имена игроков.
+	/export csv [с] [по]    - выгрузить игры и голоса лично.
+	/import [dry]           - подпись к CSV: импорт истории.
+	/add @user [да|нет]     - проголосовать за игрока или гостя без телеграма
+	/remove @user           - удалить голос игрока
+	/audit                  - кто и как менял голосование
+	/reason работа          - причина, почему не идёшь
+	/stats [с] [по]         - кто сколько ходил и почему нет
+	/balance [@user сумма]  - баланс, казначей меняет баланс игрока
+	/drive [места]          - подвезти на игру, /drive off отменяет
+	/ride                   - попроситься в машину, /ride off отменяет
+	/duties                 - кто сколько раз дежурил
+	/poll slot; slot        - опрос, когда удобно играть
+	/poll_close             - закрыть опрос и выбрать слот
+`,
+	},
+	"en": {
+		"btn.yes":                   "Yes",
+		"btn.no":                    "No",
+		"permission_denied":         "Permission denied",
+		"ok":                        "👌",
+		"set_date.format":           "Wrong format: %s, expected something like Sunday 19:00",
+		"lang.usage":                "Available languages: %s",
+		"lang.changed":              "Switched to English",
+		"reload.ok":                 "Config reloaded 👌",
+		"reload.failed":             "Config is not applied:\n%s",
+		"no_channel":                "Bot is not started in a group yet",
+		"settings.title":            "Settings:",
+		"settings.line":             "%s: %s (%s)",
+		"source.default":            "default",
+		"source.config":             "config.json",
+		"source.chat":               "changed in chat",
+		"roles.add_usage":           "Usage: /admin_add @username [%s] or reply to a message: %s",
+		"roles.remove_usage":        "Usage: /admin_remove @username or reply to a message: %s",
+		"roles.added":               "%s is %s now",
+		"roles.removed":             "%s has no role now",
+		"roles.title":               "Roles:",
+		"roles.line":                "%s - %s",
+		"roles.inherited":           "+ group admins",
+		"role.owner":                "owner",
+		"role.admin":                "admin",
+		"role.treasurer":            "treasurer",
+		"role.player":               "player",
+		"timezone.usage":            "Usage: /timezone Europe/Kiev: %s",
+		"preview.usage":             "Usage: /preview %s [sample]",
+		"preview.failed":            "Template is broken: %s",
+		"inline.title":              "⚽️ Game: %s",
+		"inline.going":              "going: %d",
+		"inline.spots":              "spots left: %d",
+		"inline.button":             "Vote",
+		"no_vote":                   "There is no vote now",
+		"notify.subscribed":         "I will send you reminders privately. Configure: /notify, quiet hours: /quiet 23-8, unsubscribe: /stop",
+		"notify.unsubscribed":       "No more private messages",
+		"notify.not_subscribed":     "You are not subscribed, press /start",
+		"notify.title":              "Private reminders:",
+		"notify.quiet":              "Quiet hours: %02d:00-%02d:00",
+		"notify.quiet_off":          "Quiet hours: off",
+		"notify.quiet_usage":        "Usage: /quiet 23-8 or /quiet off: %s",
+		"notify.kind.vote_open":     "Vote is open",
+		"notify.kind.game_tomorrow": "Game tomorrow",
+		"notify.kind.promoted":      "Promoted from waitlist",
+		"notify.vote_open":          "Vote is open, the game is on %s",
+		"notify.game_tomorrow":      "Game tomorrow: %s",
+		"notify.promoted":           "A spot is free, you are in for %s!",
+		"notify.kind.nudge":         "Haven't voted yet",
+		"notify.nudge":              "You haven't voted for the game on %s yet",
+		"nudge.group":               "%s, you haven't voted yet 👀",
+		"nick.usage":                "Usage: /nick Name, admins can /nick @user Name: %s",
+		"display.usage":             "Usage: /display %s: %s",
+		"export.usage":              "Usage: /export [%s] [2024-09-01] [2025-06-01]: %s",
+		"export.failed":             "Export failed: %s",
+		"import.done":               "Import: %d rows, %d games, %d votes, %d skipped",
+		"import.dry_run":            "Import check: %d rows, %d games, %d votes, %d skipped",
+		"import.more":               "...and %d more",
+		"import.failed":             "Import failed: %s",
+		"add.usage":                 "Usage: /add @user yes, /add Name Surname no or as a reply: %s",
+		"remove.usage":              "Usage: /remove @user or /remove Name Surname: %s",
+		"audit.title":               "Vote changes:",
+		"audit.line":                "%s %s: %s",
+		"audit.by":                  "(by %s)",
+		"audit.yes":                 "yes",
+		"audit.no":                  "no",
+		"audit.removed":             "vote removed",
+		"audit.empty":               "Nobody voted yet",
+		"reason.ask":                "Sorry to hear! What happened? Pick one or reply with a few words",
+		"reason.injury":             "injury",
+		"reason.travel":             "travelling",
+		"reason.work":               "work",
+		"reason.saved":              "Got it, reason: %s",
+		"reason.usage":              "Usage: /reason work after voting no: %s",
+		"stats.usage":               "Usage: /stats [from] [to], dates are 2006-01-02: %s",
+		"stats.title":               "Games: %d",
+		"stats.player":              "%s — yes %d, no %d",
+		"stats.reasons":             "Reasons: %s",
+		"stats.late":                "late cancellations %d",
+		"stats.late_total":          "Late cancellations: %d",
+		"balance.own":               "Your balance: %d",
+		"balance.line":              "%s: %d",
+		"balance.usage":             "Usage: /balance @user 500 or /balance @user -200: %s",
+		"btn.drive":                 "🚗 I can drive (%d seats)",
+		"btn.ride":                  "🙋 Need a ride",
+		"carpool.title":             "Carpool:",
+		"carpool.unmatched":         "🙋 need a ride:",
+		"carpool.not_going":         "Vote yes first",
+		"carpool.usage":             "Usage: /drive 3, /drive off, /ride or /ride off, only if you are going: %s",
+		"carpool.disabled":          "Carpool is disabled",
+		"carpool.passengers":        "You give a ride to the game to:%s",
+		"carpool.no_passengers":     "Nobody needs a ride with you yet",
+		"btn.duty":                  "🔄 Pass my duty",
+		"duty.title":                "Who brings what:",
+		"duty.cannot_pass":          "You have no duty or nobody can take it",
+		"duty.history":              "Duties so far:",
+		"duty.empty":                "No duties yet",
+		"poll.title":                "When do we play? Mark every slot you can make:",
+		"poll.results":              "Poll is closed, results:",
+		"poll.marked":               "Marked",
+		"poll.unmarked":             "Unmarked",
+		"poll.closed":               "The poll is closed",
+		"poll.usage":                "Usage: /poll 2024-06-01 18:00; 02.06 19:30, 2 to 8 slots in the future: %s",
+		"poll.no_poll":              "No open poll",
+		"poll.promoted":             "The vote is created",
+		"poll.cannot_promote":       "Cannot schedule the game at this slot",
+		"btn.poll_promote":          "Play %s",
+		"waitlist.title":            "Waitlist:",
+		"chat_not_found":            "Chat %s is not found",
+		"help": `
+	/help                   - show this help message.
+	/start                  - start bot.
+	/where                  - show place where we playing.
+	/info                   - show info.
+	/result                 - last vote result.
+	/lang                   - change chat language.
+	/reload                 - reload config file.
+	/settings               - show effective settings.
+	/admins                 - list roles.
+	/admin_add @user [role] - grant role.
+	/admin_remove @user     - revoke role.
+	/timezone Europe/Kiev   - set timezone of games.
+	/preview vote [sample]  - preview template privately.
+	/notify                 - private reminders.
+	/quiet 23-8             - quiet hours of reminders.
+	/stop                   - unsubscribe from reminders.
+	/nick Name              - nickname in chat, empty resets it.
+	/display nickname       - how player names are shown.
+	/export csv [from] [to] - export games and votes privately.
+	/import [dry]           - caption of CSV: import history.
+	/add @user [yes|no]     - vote on behalf of a player or a guest without telegram
+	/remove @user           - remove vote of a player
+	/audit                  - who changed the vote and how
+	/reason work            - why you are not going
+	/stats [from] [to]      - attendance and reasons of refusals
+	/balance [@user amount] - balance, treasurers change balance of a player
+	/drive [seats]          - give a ride to the game, /drive off withdraws
+	/ride                   - ask for a ride, /ride off withdraws
+	/duties                 - how many times everyone had a duty
+	/poll slot; slot        - poll which time suits everyone
+	/poll_close             - close the poll and pick the slot
+`,
+	},
+}
+
+// Languages - return codes of all known languages.
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		langs = append(langs, l)
+	}
+	sort.Strings(langs)
+
+	return langs
+}
+
+// Language - return language of chat.
+func (b *Bot) Language(chatID int64) string {
+	s, err := b.Settings(chatID)
+	if err != nil {
+		log.Printf("cannot get language of chat %d: %s", chatID, err)
+	}
+
+	return s.Language
+}
+
+// SetLanguage - change language of chat.
+func (b *Bot) SetLanguage(chatID int64, lang string) error {
+	if _, ok := catalogs[lang]; !ok {
+		return fmt.Errorf("unknown language %q", lang)
+	}
+
+	return b.Store.SetSetting(chatID, languageSetting, lang)
+}
+
+// T - return message `key` translated to the language of chat,
+// `args` are formatted into it. Missing keys fall back to the default language.
+func (b *Bot) T(chatID int64, key string, args ...interface{}) string {
+	return translate(b.Language(chatID), key, args...)
+}
+
+func translate(lang, key string, args ...interface{}) string {
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		msg, ok = catalogs[DefaultLanguage][key]
+	}
+	if !ok {
+		return key
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+
+	return msg
+}
@@ -0,0 +1,223 @@
+package vote
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/pkg/errors"
+)
+
+// importDateLayouts - accepted layouts of the date column, dates without
+// time get the game time of the chat schedule.
+var importDateLayouts = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02", "02.01.2006 15:04", "02.01.2006"}
+
+// ImportReport - result of attendance import.
+type ImportReport struct {
+	DryRun bool
+	// Rows - number of data rows read.
+	Rows int
+	// Games and Votes - number of games and votes created or updated.
+	Games int
+	Votes int
+	// Unmatched - rows skipped with the reason.
+	Unmatched []ImportProblem
+}
+
+// ImportProblem - row of import that is skipped.
+type ImportProblem struct {
+	Line   int
+	Reason string
+}
+
+func (p ImportProblem) String() string {
+	return fmt.Sprintf("line %d: %s", p.Line, p.Reason)
+}
+
+// importRow - matched row of import.
+type importRow struct {
+	date   time.Time
+	player *model.Player
+	agree  bool
+}
+
+// ImportAttendance - create past games and votes of chat from CSV with a header of
+// `date`, `user_id` or `username`, optional `name` and `option` (yes by default) columns.
+// Players are matched by user id or username, rows with unknown players are reported
+// with their name and skipped.
+// Players backfilled from old votes without chat are matched too and adopted by the chat.
+// The last row of a player in a game wins. Games are saved at once, nothing is written with `dryRun`.
+func (b *Bot) ImportAttendance(r io.Reader, chatID int64, dryRun bool) (*ImportReport, error) {
+	settings, err := b.Settings(chatID)
+	if err != nil {
+		return nil, err
+	}
+	loc := settings.Location()
+
+	players, err := b.chatPlayers(chatID)
+	if err != nil {
+		return nil, err
+	}
+	byUsername := map[string]*model.Player{}
+	byID := map[int]*model.Player{}
+	for i := range players {
+		p := &players[i]
+		byID[p.UserID] = p
+		if p.Username != "" {
+			byUsername[strings.ToLower(p.Username)] = p
+		}
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read header")
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := cols["game_date"]; ok {
+		cols["date"] = cols["game_date"]
+	}
+	if _, ok := cols["date"]; !ok {
+		return nil, errors.New("header has no date column")
+	}
+	_, hasID := cols["user_id"]
+	_, hasUsername := cols["username"]
+	if !hasID && !hasUsername {
+		return nil, errors.New("header has neither user_id nor username column")
+	}
+
+	report := &ImportReport{DryRun: dryRun}
+	var rows []importRow
+	// seen - index of row by game and user, repeated rows replace it like the vote upsert does.
+	seen := map[[2]int64]int{}
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read csv")
+		}
+		report.Rows++
+
+		field := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		skip := func(format string, args ...interface{}) {
+			report.Unmatched = append(report.Unmatched, ImportProblem{Line: line, Reason: fmt.Sprintf(format, args...)})
+		}
+
+		date, err := parseImportDate(field("date"), loc, settings.Hour, settings.Minute)
+		if err != nil {
+			skip("%s", err)
+			continue
+		}
+
+		agree := true
+		if o := strings.ToLower(field("option")); o != "" {
+			var ok bool
+			if agree, ok = ParseVoteOption(o); !ok {
+				skip("unknown option %q", o)
+				continue
+			}
+		}
+
+		username := strings.TrimPrefix(field("username"), "@")
+		var player *model.Player
+		if id, err := strconv.Atoi(field("user_id")); err == nil && id != 0 {
+			player = byID[id]
+			if player == nil {
+				// a typo in the id must not create a player with made up history.
+				if name := field("name"); name != "" {
+					skip("unknown player %d (%s)", id, name)
+				} else {
+					skip("unknown player %d", id)
+				}
+				continue
+			}
+		} else if username != "" {
+			player = byUsername[strings.ToLower(username)]
+			if player == nil {
+				skip("unknown player @%s", username)
+				continue
+			}
+		} else {
+			skip("no user_id or username")
+			continue
+		}
+
+		row := importRow{date: date, player: player, agree: agree}
+		key := [2]int64{date.Unix(), int64(player.UserID)}
+		if i, ok := seen[key]; ok {
+			rows[i] = row
+			continue
+		}
+		seen[key] = len(rows)
+		rows = append(rows, row)
+	}
+
+	// games in order of their first row.
+	var games []model.ImportedGame
+	byDate := map[int64]int{}
+	yesBtn, noBtn := b.getButtons()
+	for _, row := range rows {
+		i, ok := byDate[row.date.Unix()]
+		if !ok {
+			i = len(games)
+			byDate[row.date.Unix()] = i
+			games = append(games, model.ImportedGame{Date: row.date})
+		}
+
+		pressed := noBtn.Data
+		if row.agree {
+			pressed = yesBtn.Data
+		}
+		games[i].Votes = append(games[i].Votes, model.Vote{
+			Model:      gorm.Model{CreatedAt: row.date, UpdatedAt: row.date},
+			UserID:     row.player.UserID,
+			PressedBtn: pressed,
+			Player:     *row.player,
+		})
+	}
+	report.Games, report.Votes = len(games), len(rows)
+
+	if dryRun {
+		return report, nil
+	}
+
+	// every row is checked above, the games are saved at once or not at all.
+	if err := b.Store.ImportGames(chatID, games); err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+func parseImportDate(s string, loc *time.Location, hour, minute int) (time.Time, error) {
+	for _, layout := range importDateLayouts {
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err != nil {
+			continue
+		}
+		if !strings.Contains(layout, "15") {
+			t = time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, loc)
+		}
+		return t, nil
+	}
+
+	return time.Time{}, errors.Errorf("wrong date %q", s)
+}
@@ -0,0 +1,83 @@
+package vote
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+)
+
+func TestImportUnknownPlayer(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	if _, err := b.RegisterPlayer(testChannel.ID, testMax); err != nil {
+		t.Fatal(err)
+	}
+
+	csv := "date,user_id,name\n2024-01-10,10,Max\n2024-01-10,99,Typo\n"
+	for _, dryRun := range []bool{true, false} {
+		report, err := b.ImportAttendance(strings.NewReader(csv), testChannel.ID, dryRun)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Rows != 2 || report.Games != 1 || report.Votes != 1 {
+			t.Errorf("dry run %t: unexpected report %+v", dryRun, report)
+		}
+		if len(report.Unmatched) != 1 || !strings.Contains(report.Unmatched[0].String(), "99 (Typo)") {
+			t.Errorf("dry run %t: unknown player is not reported: %v", dryRun, report.Unmatched)
+		}
+	}
+
+	if p, err := b.Store.GetPlayer(testChannel.ID, 99); err != nil || p != nil {
+		t.Errorf("import created player %+v (%v)", p, err)
+	}
+}
+
+func TestImportGameIDs(t *testing.T) {
+	b, _ := newTestBot(t, nil)
+	other := int64(-200)
+	for _, chatID := range []int64{testChannel.ID, other} {
+		if _, err := b.RegisterPlayer(chatID, testMax); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	games := func(chatID int64) []model.Game {
+		t.Helper()
+		games, err := b.Store.GetGames(chatID, time.Time{}, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return games
+	}
+
+	// the same game time in two chats and a game before 1970.
+	csv := "date,user_id,option\n2024-01-10 19:00,10,yes\n1969-12-31 19:00,10,no\n"
+	for _, chatID := range []int64{testChannel.ID, other, testChannel.ID} {
+		if _, err := b.ImportAttendance(strings.NewReader(csv), chatID, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := map[int]bool{}
+	for _, chatID := range []int64{testChannel.ID, other} {
+		list := games(chatID)
+		if len(list) != 2 {
+			t.Fatalf("chat %d has games %+v, want 2", chatID, list)
+		}
+		for _, g := range list {
+			if g.VoteID >= 0 || ids[g.VoteID] {
+				t.Errorf("game %+v has no vote id of its own", g)
+			}
+			ids[g.VoteID] = true
+
+			votes, err := b.Store.GetVotesByVoteID(g.VoteID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(votes) != 1 {
+				t.Errorf("game %+v has votes %+v, want 1", g, votes)
+			}
+		}
+	}
+}
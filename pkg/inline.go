@@ -0,0 +1,88 @@
+package vote
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// VoteStartPayload - payload of the deep link that opens the vote in a private chat.
+const VoteStartPayload = "vote"
+
+// inlineCacheTime - seconds telegram may cache the inline answer, the summary changes often.
+const inlineCacheTime = 30
+
+// VoteLink - deep link to the bot that sends the current vote privately.
+func (b *Bot) VoteLink() string {
+	return fmt.Sprintf("https://t.me/%s?start=%s", b.Me.Username, VoteStartPayload)
+}
+
+// AnswerQuery - answer inline query with summary of the current game
+// and a button to vote through the bot.
+func (b *Bot) AnswerQuery(q *tb.Query) error {
+	resp := &tb.QueryResponse{CacheTime: inlineCacheTime}
+
+	if b.Pinned != nil {
+		lang := b.channelLanguage()
+		if code := strings.SplitN(q.From.LanguageCode, "-", 2)[0]; catalogs[code] != nil {
+			lang = code
+		}
+
+		data, err := b.voteData()
+		if err != nil {
+			return err
+		}
+
+		title := translate(lang, "inline.title", formatDate(data.Date.Time, "Monday, 2 January 15:04", lang))
+		var details []string
+		if data.Venue.Name != "" {
+			details = append(details, data.Venue.Name)
+		}
+		details = append(details, translate(lang, "inline.going", data.Going.Count))
+		if data.Limit > 0 {
+			details = append(details, translate(lang, "inline.spots", data.SpotsLeft))
+		}
+		description := strings.Join(details, ", ")
+
+		var content tb.InputMessageContent = &tb.InputTextMessageContent{
+			Text:           title + "\n" + description + "\n" + data.Venue.URL,
+			DisablePreview: true,
+		}
+
+		result := &tb.ArticleResult{Title: title, Description: description}
+		result.Content = &content
+		result.ReplyMarkup = &tb.InlineKeyboardMarkup{
+			InlineKeyboard: [][]tb.InlineButton{{{Text: translate(lang, "inline.button"), URL: b.VoteLink()}}},
+		}
+		result.SetResultID(fmt.Sprintf("vote_%d", b.getMsgID()))
+
+		resp.Results = tb.Results{result}
+	}
+
+	if err := b.Answer(q, resp); err != nil {
+		return errors.Wrap(err, "cannot answer inline query")
+	}
+
+	return nil
+}
+
+// SendVote - send copy of the current vote with buttons to recipient,
+// votes made with it are counted in the pinned one.
+func (b *Bot) SendVote(to tb.Recipient) error {
+	if b.Pinned == nil {
+		return errors.New("no vote")
+	}
+
+	msg, mkp, parseMode, err := b.getVoteMessage()
+	if err != nil {
+		return err
+	}
+
+	if _, err := b.Send(to, msg, mkp, parseMode); err != nil {
+		return errors.Wrap(err, "cannot send vote")
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package vote
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Parse modes of message templates.
+const (
+	ModeMarkdown   = "Markdown"
+	ModeMarkdownV2 = "MarkdownV2"
+	ModeHTML       = "HTML"
+)
+
+// ParseModes - all supported parse modes.
+var ParseModes = []string{ModeMarkdown, ModeMarkdownV2, ModeHTML}
+
+var (
+	markdownEscaper = strings.NewReplacer(
+		"_", `\_`, "*", `\*`, "`", "\\`", "[", `\[`,
+	)
+	markdownV2Escaper = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+		"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`,
+		"=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	)
+)
+
+// markup - escaping rules of telegram parse mode.
+type markup string
+
+func (m markup) parseMode() tb.ParseMode {
+	return tb.ParseMode(m)
+}
+
+// escape - make user content safe to put into the message as plain text.
+func (m markup) escape(s string) string {
+	switch m {
+	case ModeMarkdownV2:
+		return markdownV2Escaper.Replace(s)
+	case ModeHTML:
+		return html.EscapeString(s)
+	default:
+		return markdownEscaper.Replace(s)
+	}
+}
+
+// mention - link to telegram user with escaped name,
+// players without telegram account get the name only.
+func (m markup) mention(name string, userID int) string {
+	if userID <= 0 {
+		return m.escape(name)
+	}
+
+	switch m {
+	case ModeMarkdownV2:
+		return fmt.Sprintf("[%s](tg://user?id=%d)", m.escape(name), userID)
+	case ModeHTML:
+		return fmt.Sprintf(`<a href="tg://user?id=%d">%s</a>`, userID, m.escape(name))
+	default:
+		// legacy markdown cannot escape the closing bracket of a link text.
+		name = strings.Replace(name, "]", ")", -1)
+		return fmt.Sprintf("[%s](tg://user?id=%d)", m.escape(name), userID)
+	}
+}
+
+// markup - return escaping rules of configured parse mode.
+func (b *Bot) markup() markup {
+	mode := b.Config().ParseMode
+	if mode == "" {
+		return ModeMarkdown
+	}
+
+	return markup(mode)
+}
+
+// ParseMode - return telegram parse mode of configured formats.
+func (b *Bot) ParseMode() tb.ParseMode {
+	return b.markup().parseMode()
+}
@@ -0,0 +1,31 @@
+package vote
+
+import "testing"
+
+func TestMarkup(t *testing.T) {
+	tests := []struct {
+		mode    markup
+		name    string
+		escape  string
+		mention string
+	}{
+		{ModeMarkdown, "Max_", `Max\_`, `[Max\_](tg://user?id=10)`},
+		{ModeMarkdownV2, "Max_", `Max\_`, `[Max\_](tg://user?id=10)`},
+		{ModeHTML, "Max_", `Max_`, `<a href="tg://user?id=10">Max_</a>`},
+		{ModeMarkdown, "*[b] & <", `\*\[b] & <`, `[\*\[b) & <](tg://user?id=10)`},
+		{ModeMarkdownV2, "*[b] & <", `\*\[b\] & <`, `[\*\[b\] & <](tg://user?id=10)`},
+		{ModeHTML, "*[b] & <", `*[b] &amp; &lt;`, `<a href="tg://user?id=10">*[b] &amp; &lt;</a>`},
+	}
+	for _, tt := range tests {
+		if got := tt.mode.escape(tt.name); got != tt.escape {
+			t.Errorf("%s escape(%q) = %q, want %q", tt.mode, tt.name, got, tt.escape)
+		}
+		if got := tt.mode.mention(tt.name, 10); got != tt.mention {
+			t.Errorf("%s mention(%q) = %q, want %q", tt.mode, tt.name, got, tt.mention)
+		}
+		// players without telegram account are not linked.
+		if got := tt.mode.mention(tt.name, 0); got != tt.escape {
+			t.Errorf("%s mention(%q) without account = %q, want %q", tt.mode, tt.name, got, tt.escape)
+		}
+	}
+}
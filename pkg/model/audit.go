@@ -0,0 +1,77 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// Actions of vote audit records.
+const (
+	AuditVote   = "vote"
+	AuditRemove = "remove"
+)
+
+// VoteAudit - change of player vote, made by the player or by admin on their behalf.
+type VoteAudit struct {
+	gorm.Model
+
+	VoteID   int  `json:"vote_id"`
+	UserID   int  `json:"user_id"`
+	PlayerID uint `json:"player_id"`
+	// ActorID - user id of who made the change.
+	ActorID    int    `json:"actor_id"`
+	Action     string `json:"action"`
+	PressedBtn string `json:"pressed_btn"`
+}
+
+// Proxied - report whether the change is made on behalf of the player.
+func (a VoteAudit) Proxied() bool {
+	return a.ActorID != a.UserID
+}
+
+// AddVoteAudit - record change of vote.
+func (e *Engine) AddVoteAudit(a *VoteAudit) error {
+	if err := e.Create(a).Error; err != nil {
+		return errors.Wrapf(err, "cannot audit vote of user %d", a.UserID)
+	}
+
+	return nil
+}
+
+// GetVoteAudits - return changes of vote `voteID` in order they were made.
+func (e *Engine) GetVoteAudits(voteID int) ([]VoteAudit, error) {
+	var audits []VoteAudit
+
+	if err := e.Where(VoteAudit{VoteID: voteID}).Order("id").Find(&audits).Error; err != nil {
+		return nil, errors.Wrapf(err, "cannot get audit of vote %d", voteID)
+	}
+
+	return audits, nil
+}
+
+// AddVoteAudit - record change of vote.
+func (s *MemoryStore) AddVoteAudit(a *VoteAudit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a.ID, a.CreatedAt = s.nextID()
+	a.UpdatedAt = a.CreatedAt
+	s.audits = append(s.audits, *a)
+
+	return nil
+}
+
+// GetVoteAudits - return changes of vote `voteID` in order they were made.
+func (s *MemoryStore) GetVoteAudits(voteID int) ([]VoteAudit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var audits []VoteAudit
+	for _, a := range s.audits {
+		if a.VoteID == voteID {
+			audits = append(audits, a)
+		}
+	}
+
+	return audits, nil
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// LateCancellation - confirmed player refused shortly before the game.
+type LateCancellation struct {
+	gorm.Model
+
+	ChatID   int64 `json:"chat_id"`
+	VoteID   int   `json:"vote_id"`
+	UserID   int   `json:"user_id"`
+	PlayerID uint  `json:"player_id"`
+	// Fee - amount charged to the player balance, 0 if none.
+	Fee int `json:"fee"`
+}
+
+// GetLateCancellations - return late cancellations of chat.
+func (e *Engine) GetLateCancellations(chatID int64) ([]LateCancellation, error) {
+	var list []LateCancellation
+
+	if err := e.Where(LateCancellation{ChatID: chatID}).Order("id").Find(&list).Error; err != nil {
+		return nil, errors.Wrapf(err, "cannot get late cancellations of chat %d", chatID)
+	}
+
+	return list, nil
+}
+
+// GetLateCancellation - return late cancellation of user in vote `voteID`, nil if there is none.
+func (e *Engine) GetLateCancellation(voteID, userID int) (*LateCancellation, error) {
+	var c LateCancellation
+
+	err := e.Where(LateCancellation{VoteID: voteID, UserID: userID}).Take(&c).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get late cancellation of user %d", userID)
+	}
+
+	return &c, nil
+}
+
+// AddLateCancellation - record late cancellation.
+func (e *Engine) AddLateCancellation(c *LateCancellation) error {
+	if err := e.Create(c).Error; err != nil {
+		return errors.Wrapf(err, "cannot add late cancellation of user %d", c.UserID)
+	}
+
+	return nil
+}
+
+// GetLateCancellations - return late cancellations of chat.
+func (s *MemoryStore) GetLateCancellations(chatID int64) ([]LateCancellation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []LateCancellation
+	for _, c := range s.cancellations {
+		if c.ChatID == chatID {
+			list = append(list, c)
+		}
+	}
+
+	return list, nil
+}
+
+// GetLateCancellation - return late cancellation of user in vote `voteID`, nil if there is none.
+func (s *MemoryStore) GetLateCancellation(voteID, userID int) (*LateCancellation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.cancellations {
+		if c.VoteID == voteID && c.UserID == userID {
+			found := c
+			return &found, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// AddLateCancellation - record late cancellation.
+func (s *MemoryStore) AddLateCancellation(c *LateCancellation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c.ID, c.CreatedAt = s.nextID()
+	c.UpdatedAt = c.CreatedAt
+	s.cancellations = append(s.cancellations, *c)
+
+	return nil
+}
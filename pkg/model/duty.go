@@ -0,0 +1,110 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// Duty - equipment duty of player in vote, like bringing the ball.
+type Duty struct {
+	gorm.Model
+
+	ChatID   int64  `json:"chat_id"`
+	VoteID   int    `json:"vote_id"`
+	Name     string `json:"name"`
+	UserID   int    `json:"user_id"`
+	PlayerID uint   `json:"player_id"`
+	// Passed - comma separated user ids who passed the duty to others in the vote.
+	Passed string `json:"passed"`
+}
+
+// GetDuties - return duties of chat in order they were assigned.
+func (e *Engine) GetDuties(chatID int64) ([]Duty, error) {
+	var duties []Duty
+
+	if err := e.Where(Duty{ChatID: chatID}).Order("id").Find(&duties).Error; err != nil {
+		return nil, errors.Wrapf(err, "cannot get duties of chat %d", chatID)
+	}
+
+	return duties, nil
+}
+
+// GetVoteDuties - return duties of vote `voteID` in order they were assigned.
+func (e *Engine) GetVoteDuties(voteID int) ([]Duty, error) {
+	var duties []Duty
+
+	if err := e.Where(Duty{VoteID: voteID}).Order("id").Find(&duties).Error; err != nil {
+		return nil, errors.Wrapf(err, "cannot get duties of vote %d", voteID)
+	}
+
+	return duties, nil
+}
+
+// SaveDuty - create duty of vote or update who has it, `d` gets the stored duty.
+func (e *Engine) SaveDuty(d *Duty) error {
+	var duty Duty
+
+	err := e.Where(Duty{VoteID: d.VoteID, Name: d.Name}).
+		Assign(map[string]interface{}{"chat_id": d.ChatID, "user_id": d.UserID, "player_id": d.PlayerID, "passed": d.Passed}).
+		FirstOrCreate(&duty).Error
+	if err != nil {
+		return errors.Wrapf(err, "cannot save duty %q", d.Name)
+	}
+	*d = duty
+
+	return nil
+}
+
+// GetDuties - return duties of chat in order they were assigned.
+func (s *MemoryStore) GetDuties(chatID int64) ([]Duty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var duties []Duty
+	for _, d := range s.duties {
+		if d.ChatID == chatID {
+			duties = append(duties, d)
+		}
+	}
+
+	return duties, nil
+}
+
+// GetVoteDuties - return duties of vote `voteID` in order they were assigned.
+func (s *MemoryStore) GetVoteDuties(voteID int) ([]Duty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var duties []Duty
+	for _, d := range s.duties {
+		if d.VoteID == voteID {
+			duties = append(duties, d)
+		}
+	}
+
+	return duties, nil
+}
+
+// SaveDuty - create duty of vote or update who has it, `d` gets the stored duty.
+func (s *MemoryStore) SaveDuty(d *Duty) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, old := range s.duties {
+		if old.VoteID == d.VoteID && old.Name == d.Name {
+			old.ChatID, old.UserID, old.PlayerID, old.Passed = d.ChatID, d.UserID, d.PlayerID, d.Passed
+			old.UpdatedAt = time.Now()
+			s.duties[i] = old
+			*d = old
+			return nil
+		}
+	}
+
+	d.ID, d.CreatedAt = s.nextID()
+	d.UpdatedAt = d.CreatedAt
+	s.duties = append(s.duties, *d)
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
 	// for mysql usage
 	_ "github.com/jinzhu/gorm/dialects/mysql"
 )
@@ -22,13 +23,14 @@ var (
 )
 
 var instance *Engine
+var instanceErr error
 var once sync.Once
 
-// Engine - base engine connector to data store.
+// Engine - base engine connector to data store, GORM implementation of Store.
 type Engine struct{ *gorm.DB }
 
 // GetEngine - return engine instance.
-func GetEngine() *Engine {
+func GetEngine() (*Engine, error) {
 	once.Do(func() {
 		user = os.Getenv("DB_USER")
 		pass = os.Getenv("DB_PASS")
@@ -46,16 +48,17 @@ func GetEngine() *Engine {
 			"&",
 		)
 
-		db, _ := gorm.Open(
+		db, err := gorm.Open(
 			"mysql",
 			fmt.Sprintf("%s:%s@tcp(%s)/%s?%s", user, pass, net.JoinHostPort(host, port), dbName, options),
 		)
-
-		db.AutoMigrate(&Vote{})
-		db.AutoMigrate(&Reminder{})
+		if err != nil {
+			instanceErr = errors.Wrap(err, "cannot connect to database")
+			return
+		}
 
 		instance = &Engine{db}
 	})
 
-	return instance
+	return instance, instanceErr
 }
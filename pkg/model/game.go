@@ -0,0 +1,229 @@
+package model
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// Game - game of chat with its vote.
+type Game struct {
+	gorm.Model
+
+	ChatID int64     `json:"chat_id"`
+	VoteID int       `json:"vote_id"`
+	Date   time.Time `json:"date"`
+	// Special - the game is played at Date instead of the chat schedule.
+	Special bool `json:"special"`
+}
+
+// ImportedGame - past game of chat with votes of its players.
+type ImportedGame struct {
+	Date time.Time
+	// Votes - votes of the game, players of votes without chat are adopted by the chat.
+	Votes []Vote
+}
+
+// GetGames - return games of chat played in [from, to) ordered by date.
+func (e *Engine) GetGames(chatID int64, from, to time.Time) ([]Game, error) {
+	var games []Game
+
+	err := e.Where("chat_id = ? AND date >= ? AND date < ?", chatID, from, to).
+		Order("date").
+		Find(&games).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get games of chat %d", chatID)
+	}
+
+	return games, nil
+}
+
+// GetGame - return game of vote in chat, nil if there is none.
+func (e *Engine) GetGame(chatID int64, voteID int) (*Game, error) {
+	var game Game
+
+	err := e.Where(Game{ChatID: chatID, VoteID: voteID}).Take(&game).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get game of vote %d", voteID)
+	}
+
+	return &game, nil
+}
+
+// SaveGame - create game of vote in chat or update its date, `g` gets the stored game.
+// Special games stay special.
+func (e *Engine) SaveGame(g *Game) error {
+	var game Game
+
+	err := e.Where(Game{ChatID: g.ChatID, VoteID: g.VoteID}).
+		Assign(Game{Date: g.Date, Special: g.Special}).
+		FirstOrCreate(&game).Error
+	if err != nil {
+		return errors.Wrapf(err, "cannot save game of vote %d", g.VoteID)
+	}
+	*g = game
+
+	return nil
+}
+
+// ImportGames - save past games of chat with their votes, nothing is saved on error.
+// Imported games get negative vote ids of their own sequence, so they never meet telegram
+// message ids of any chat, and a game imported again at the same date keeps its id.
+func (e *Engine) ImportGames(chatID int64, games []ImportedGame) error {
+	tx := e.Begin()
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "cannot begin import transaction")
+	}
+
+	if err := (&Engine{tx}).importGames(chatID, games); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return errors.Wrap(tx.Commit().Error, "cannot commit import")
+}
+
+func (e *Engine) importGames(chatID int64, games []ImportedGame) error {
+	var first struct{ ID int }
+
+	// the lock keeps concurrent imports from taking the same ids.
+	err := e.Unscoped().Model(&Game{}).Set("gorm:query_option", "FOR UPDATE").
+		Select("COALESCE(MIN(vote_id), 0) AS id").Scan(&first).Error
+	if err != nil {
+		return errors.Wrap(err, "cannot get the first vote id of imported games")
+	}
+	lastID := first.ID
+	if lastID > 0 {
+		lastID = 0
+	}
+
+	for _, g := range games {
+		var game Game
+		err := e.Where("chat_id = ? AND vote_id < 0 AND date = ?", chatID, g.Date).Take(&game).Error
+		if gorm.IsRecordNotFoundError(err) {
+			lastID--
+			game = Game{ChatID: chatID, VoteID: lastID, Date: g.Date}
+			err = e.Create(&game).Error
+		}
+		if err != nil {
+			return errors.Wrapf(err, "cannot save game of chat %d at %s", chatID, g.Date)
+		}
+
+		for _, v := range g.Votes {
+			if v.Player.ChatID != chatID {
+				v.Player.ChatID = chatID
+				if err := e.SavePlayer(&v.Player); err != nil {
+					return err
+				}
+			}
+			v.VoteID, v.PlayerID = game.VoteID, v.Player.ID
+			if _, err := e.CreateVote(&v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetGames - return games of chat played in [from, to) ordered by date.
+func (s *MemoryStore) GetGames(chatID int64, from, to time.Time) ([]Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var games []Game
+	for _, g := range s.games {
+		if g.ChatID == chatID && !g.Date.Before(from) && g.Date.Before(to) {
+			games = append(games, g)
+		}
+	}
+	sort.SliceStable(games, func(i, j int) bool { return games[i].Date.Before(games[j].Date) })
+
+	return games, nil
+}
+
+// GetGame - return game of vote in chat, nil if there is none.
+func (s *MemoryStore) GetGame(chatID int64, voteID int) (*Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, g := range s.games {
+		if g.ChatID == chatID && g.VoteID == voteID {
+			game := g
+			return &game, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// SaveGame - create game of vote in chat or update its date, `g` gets the stored game.
+// Special games stay special.
+func (s *MemoryStore) SaveGame(g *Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, old := range s.games {
+		if old.ChatID == g.ChatID && old.VoteID == g.VoteID {
+			s.games[i].Date = g.Date
+			s.games[i].Special = s.games[i].Special || g.Special
+			s.games[i].UpdatedAt = time.Now()
+			*g = s.games[i]
+			return nil
+		}
+	}
+
+	g.ID, g.CreatedAt = s.nextID()
+	g.UpdatedAt = g.CreatedAt
+	s.games = append(s.games, *g)
+
+	return nil
+}
+
+// ImportGames - save past games of chat with their votes, nothing is saved on error.
+// Imported games get negative vote ids of their own sequence, so they never meet telegram
+// message ids of any chat, and a game imported again at the same date keeps its id.
+func (s *MemoryStore) ImportGames(chatID int64, games []ImportedGame) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lastID := 0
+	for _, g := range s.games {
+		if g.VoteID < lastID {
+			lastID = g.VoteID
+		}
+	}
+
+	for _, g := range games {
+		voteID := 0
+		for _, old := range s.games {
+			if old.ChatID == chatID && old.VoteID < 0 && old.Date.Equal(g.Date) {
+				voteID = old.VoteID
+			}
+		}
+		if voteID == 0 {
+			lastID--
+			voteID = lastID
+			game := Game{ChatID: chatID, VoteID: voteID, Date: g.Date}
+			game.ID, game.CreatedAt = s.nextID()
+			game.UpdatedAt = game.CreatedAt
+			s.games = append(s.games, game)
+		}
+
+		for _, v := range g.Votes {
+			if v.Player.ChatID != chatID {
+				v.Player.ChatID = chatID
+				s.savePlayer(&v.Player)
+			}
+			v.VoteID, v.PlayerID = voteID, v.Player.ID
+			s.createVote(&v)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore - in-memory implementation of Store, data lives until the process exits.
+type MemoryStore struct {
+	mu sync.Mutex
+
+	lastID        uint
+	votes         []Vote
+	reminders     []Reminder
+	settings      []Setting
+	roles         []Role
+	subscriptions []Subscription
+	players       []Player
+	games         []Game
+	audits        []VoteAudit
+	cancellations []LateCancellation
+	rides         []Ride
+	duties        []Duty
+	polls         []Poll
+	answers       []PollAnswer
+}
+
+// NewMemoryStore - return new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) nextID() (uint, time.Time) {
+	s.lastID++
+	return s.lastID, time.Now()
+}
@@ -0,0 +1,170 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+const migrationsTable = "schema_migrations"
+
+// Migration - single versioned schema change with up and down steps.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(*gorm.DB) error
+	Down    func(*gorm.DB) error
+}
+
+// MigrationState - migration with its applied state.
+type MigrationState struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type schemaMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrate - apply all pending migrations in version order.
+func (e *Engine) Migrate() error {
+	applied, err := e.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range sortedMigrations() {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		err := e.runMigration(m, m.Up, func(tx *gorm.DB) error {
+			return tx.Table(migrationsTable).Create(&schemaMigration{
+				Version:   m.Version,
+				Name:      m.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rollback - revert last `steps` applied migrations.
+func (e *Engine) Rollback(steps int) error {
+	applied, err := e.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	ms := sortedMigrations()
+	for i := len(ms) - 1; i >= 0 && steps > 0; i-- {
+		m := ms[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		err := e.runMigration(m, m.Down, func(tx *gorm.DB) error {
+			return tx.Exec("DELETE FROM "+migrationsTable+" WHERE version = ?", m.Version).Error
+		})
+		if err != nil {
+			return err
+		}
+		steps--
+	}
+
+	return nil
+}
+
+// MigrationStatus - return all known migrations with their applied state.
+func (e *Engine) MigrationStatus() ([]MigrationState, error) {
+	applied, err := e.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var states []MigrationState
+	for _, m := range sortedMigrations() {
+		sm, ok := applied[m.Version]
+		states = append(states, MigrationState{Migration: m, Applied: ok, AppliedAt: sm.AppliedAt})
+	}
+
+	return states, nil
+}
+
+func (e *Engine) runMigration(m Migration, step func(*gorm.DB) error, record func(*gorm.DB) error) error {
+	tx := e.Begin()
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "cannot begin migration transaction")
+	}
+
+	if step != nil {
+		if err := step(tx); err != nil {
+			tx.Rollback()
+			return errors.Wrapf(err, "migration %d_%s failed", m.Version, m.Name)
+		}
+	}
+
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return errors.Wrapf(err, "cannot record migration %d_%s", m.Version, m.Name)
+	}
+
+	return tx.Commit().Error
+}
+
+func (e *Engine) appliedMigrations() (map[int]schemaMigration, error) {
+	err := e.Exec("CREATE TABLE IF NOT EXISTS " + migrationsTable + ` (
+		version INT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NULL
+	)`).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create migrations table")
+	}
+
+	var rows []schemaMigration
+	if err := e.Table(migrationsTable).Find(&rows).Error; err != nil {
+		return nil, errors.Wrap(err, "cannot read applied migrations")
+	}
+
+	applied := make(map[int]schemaMigration, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = r
+	}
+
+	return applied, nil
+}
+
+func sortedMigrations() []Migration {
+	ms := make([]Migration, len(migrations))
+	copy(ms, migrations)
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Version < ms[j].Version })
+
+	return ms
+}
+
+// String - printable migration name.
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+func execAll(stmts ...string) func(*gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		for _, s := range stmts {
+			if err := tx.Exec(s).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
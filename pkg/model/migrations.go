@@ -0,0 +1,390 @@
+package model
+
+// migrations - every schema change of the bot, never edit applied ones,
+// append a new version instead.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_votes_and_reminders",
+		// IF NOT EXISTS keeps databases created by the old AutoMigrate intact.
+		Up: execAll(
+			`CREATE TABLE IF NOT EXISTS votes (
+				id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+				created_at TIMESTAMP NULL,
+				updated_at TIMESTAMP NULL,
+				deleted_at TIMESTAMP NULL,
+				vote_id INT,
+				user_id INT,
+				voter_name VARCHAR(255),
+				first_name VARCHAR(255),
+				last_name VARCHAR(255),
+				pressed_btn VARCHAR(255),
+				PRIMARY KEY (id),
+				INDEX idx_votes_deleted_at (deleted_at)
+			)`,
+			`CREATE TABLE IF NOT EXISTS reminders (
+				id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+				created_at TIMESTAMP NULL,
+				updated_at TIMESTAMP NULL,
+				deleted_at TIMESTAMP NULL,
+				reminder_id INT,
+				vote_id INT,
+				PRIMARY KEY (id),
+				INDEX idx_reminders_deleted_at (deleted_at)
+			)`,
+		),
+		Down: execAll(
+			"DROP TABLE IF EXISTS reminders",
+			"DROP TABLE IF EXISTS votes",
+		),
+	},
+	{
+		Version: 2,
+		Name:    "unique_vote_per_user",
+		Up: execAll(
+			// keep only the latest row of every duplicated (vote_id, user_id) pair.
+			`DELETE v1 FROM votes v1
+				JOIN votes v2 ON v1.vote_id = v2.vote_id AND v1.user_id = v2.user_id AND v1.id < v2.id`,
+			"CREATE UNIQUE INDEX uix_votes_vote_id_user_id ON votes (vote_id, user_id)",
+		),
+		Down: execAll(
+			"DROP INDEX uix_votes_vote_id_user_id ON votes",
+		),
+	},
+	{
+		Version: 3,
+		Name:    "create_settings",
+		Up: execAll(
+			`CREATE TABLE settings (
+				id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+				created_at TIMESTAMP NULL,
+				updated_at TIMESTAMP NULL,
+				deleted_at TIMESTAMP NULL,
+				chat_id BIGINT NOT NULL,
+				` + "`key`" + ` VARCHAR(64) NOT NULL,
+				value VARCHAR(255) NOT NULL,
+				PRIMARY KEY (id),
+				INDEX idx_settings_deleted_at (deleted_at),
+				UNIQUE INDEX uix_settings_chat_id_key (chat_id, ` + "`key`" + `)
+			)`,
+		),
+		Down: execAll(
+			"DROP TABLE IF EXISTS settings",
+		),
+	},
+	{
+		Version: 4,
+		Name:    "create_roles",
+		Up: execAll(
+			`CREATE TABLE roles (
+				id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+				created_at TIMESTAMP NULL,
+				updated_at TIMESTAMP NULL,
+				deleted_at TIMESTAMP NULL,
+				chat_id BIGINT NOT NULL,
+				user_id INT NOT NULL,
+				name VARCHAR(255) NOT NULL DEFAULT '',
+				role VARCHAR(32) NOT NULL,
+				PRIMARY KEY (id),
+				INDEX idx_roles_deleted_at (deleted_at),
+				UNIQUE INDEX uix_roles_chat_id_user_id (chat_id, user_id)
+			)`,
+		),
+		Down: execAll(
+			"DROP TABLE IF EXISTS roles",
+		),
+	},
+	{
+		Version: 5,
+		Name:    "add_reminder_kind",
+		// rows sent before kinds existed are group reminders.
+		Up: execAll(
+			"ALTER TABLE reminders ADD COLUMN kind VARCHAR(32) NOT NULL DEFAULT 'group'",
+			"CREATE INDEX idx_reminders_vote_id_kind ON reminders (vote_id, kind)",
+		),
+		Down: execAll(
+			"DROP INDEX idx_reminders_vote_id_kind ON reminders",
+			"ALTER TABLE reminders DROP COLUMN kind",
+		),
+	},
+	{
+		Version: 6,
+		Name:    "create_subscriptions",
+		Up: execAll(
+			`CREATE TABLE subscriptions (
+				id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+				created_at TIMESTAMP NULL,
+				updated_at TIMESTAMP NULL,
+				deleted_at TIMESTAMP NULL,
+				chat_id BIGINT NOT NULL,
+				user_id INT NOT NULL,
+				vote_open BOOLEAN NOT NULL DEFAULT TRUE,
+				game_tomorrow BOOLEAN NOT NULL DEFAULT TRUE,
+				promoted BOOLEAN NOT NULL DEFAULT TRUE,
+				quiet_from TINYINT NOT NULL DEFAULT 0,
+				quiet_to TINYINT NOT NULL DEFAULT 0,
+				PRIMARY KEY (id),
+				INDEX idx_subscriptions_deleted_at (deleted_at),
+				UNIQUE INDEX uix_subscriptions_chat_id_user_id (chat_id, user_id)
+			)`,
+		),
+		Down: execAll(
+			"DROP TABLE IF EXISTS subscriptions",
+		),
+	},
+	{
+		Version: 7,
+		Name:    "add_subscription_nudge",
+		Up: execAll(
+			"ALTER TABLE subscriptions ADD COLUMN nudge BOOLEAN NOT NULL DEFAULT TRUE",
+		),
+		Down: execAll(
+			"ALTER TABLE subscriptions DROP COLUMN nudge",
+		),
+	},
+	{
+		Version: 8,
+		Name:    "create_players",
+		// players are created from the latest vote of every user, old votes
+		// don't know their chat so the players get chat 0 until they vote again.
+		Up: execAll(
+			`CREATE TABLE players (
+				id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+				created_at TIMESTAMP NULL,
+				updated_at TIMESTAMP NULL,
+				deleted_at TIMESTAMP NULL,
+				chat_id BIGINT NOT NULL,
+				user_id INT NOT NULL,
+				username VARCHAR(255) NOT NULL DEFAULT '',
+				first_name VARCHAR(255) NOT NULL DEFAULT '',
+				last_name VARCHAR(255) NOT NULL DEFAULT '',
+				PRIMARY KEY (id),
+				INDEX idx_players_deleted_at (deleted_at),
+				UNIQUE INDEX uix_players_chat_id_user_id (chat_id, user_id)
+			)`,
+			`INSERT INTO players (created_at, updated_at, chat_id, user_id, username, first_name, last_name)
+				SELECT NOW(), NOW(), 0, v.user_id, COALESCE(v.voter_name, ''), COALESCE(v.first_name, ''), COALESCE(v.last_name, '')
+				FROM votes v
+				JOIN (SELECT user_id, MAX(id) AS id FROM votes GROUP BY user_id) latest ON latest.id = v.id`,
+			"ALTER TABLE votes ADD COLUMN player_id INT UNSIGNED NULL AFTER user_id",
+			"UPDATE votes v JOIN players p ON p.chat_id = 0 AND p.user_id = v.user_id SET v.player_id = p.id",
+			"ALTER TABLE votes DROP COLUMN voter_name, DROP COLUMN first_name, DROP COLUMN last_name",
+		),
+		Down: execAll(
+			`ALTER TABLE votes
+				ADD COLUMN voter_name VARCHAR(255) AFTER user_id,
+				ADD COLUMN first_name VARCHAR(255) AFTER voter_name,
+				ADD COLUMN last_name VARCHAR(255) AFTER first_name`,
+			`UPDATE votes v JOIN players p ON p.id = v.player_id
+				SET v.voter_name = p.username, v.first_name = p.first_name, v.last_name = p.last_name`,
+			"ALTER TABLE votes DROP COLUMN player_id",
+			"DROP TABLE IF EXISTS players",
+		),
+	},
+	{
+		Version: 9,
+		Name:    "add_player_nickname",
+		Up: execAll(
+			"ALTER TABLE players ADD COLUMN nickname VARCHAR(64) NOT NULL DEFAULT ''",
+		),
+		Down: execAll(
+			"ALTER TABLE players DROP COLUMN nickname",
+		),
+	},
+	{
+		Version: 10,
+		Name:    "create_games",
+		// old votes don't know their chat and game time, the games get chat 0
+		// and the time of the first vote.
+		Up: execAll(
+			`CREATE TABLE games (
+				id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+				created_at TIMESTAMP NULL,
+				updated_at TIMESTAMP NULL,
+				deleted_at TIMESTAMP NULL,
+				chat_id BIGINT NOT NULL,
+				vote_id INT NOT NULL,
+				date TIMESTAMP NULL,
+				PRIMARY KEY (id),
+				INDEX idx_games_deleted_at (deleted_at),
+				INDEX idx_games_chat_id_date (chat_id, date),
+				UNIQUE INDEX uix_games_chat_id_vote_id (chat_id, vote_id)
+			)`,
+			`INSERT INTO games (created_at, updated_at, chat_id, vote_id, date)
+				SELECT NOW(), NOW(), 0, vote_id, MIN(created_at) FROM votes GROUP BY vote_id`,
+		),
+		Down: execAll(
+			"DROP TABLE IF EXISTS games",
+		),
+	},
+	{
+		Version: 11,
+		Name:    "create_vote_audits",
+		Up: execAll(
+			"ALTER TABLE votes ADD COLUMN proxy_by INT NOT NULL DEFAULT 0",
+			`CREATE TABLE vote_audits (
+				id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+				created_at TIMESTAMP NULL,
+				updated_at TIMESTAMP NULL,
+				deleted_at TIMESTAMP NULL,
+				vote_id INT NOT NULL,
+				user_id INT NOT NULL,
+				player_id INT UNSIGNED NULL,
+				actor_id INT NOT NULL,
+				action VARCHAR(16) NOT NULL,
+				pressed_btn VARCHAR(255) NOT NULL DEFAULT '',
+				PRIMARY KEY (id),
+				INDEX idx_vote_audits_deleted_at (deleted_at),
+				INDEX idx_vote_audits_vote_id (vote_id)
+			)`,
+		),
+		Down: execAll(
+			"DROP TABLE IF EXISTS vote_audits",
+			"ALTER TABLE votes DROP COLUMN proxy_by",
+		),
+	},
+	{
+		Version: 12,
+		Name:    "add_vote_reason",
+		Up: execAll(
+			"ALTER TABLE votes ADD COLUMN reason VARCHAR(255) NOT NULL DEFAULT ''",
+		),
+		Down: execAll(
+			"ALTER TABLE votes DROP COLUMN reason",
+		),
+	},
+	{
+		Version: 13,
+		Name:    "create_late_cancellations",
+		Up: execAll(
+			"ALTER TABLE players ADD COLUMN balance INT NOT NULL DEFAULT 0",
+			`CREATE TABLE late_cancellations (
+				id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+				created_at TIMESTAMP NULL,
+				updated_at TIMESTAMP NULL,
+				deleted_at TIMESTAMP NULL,
+				chat_id BIGINT NOT NULL,
+				vote_id INT NOT NULL,
+				user_id INT NOT NULL,
+				player_id INT UNSIGNED NULL,
+				fee INT NOT NULL DEFAULT 0,
+				PRIMARY KEY (id),
+				INDEX idx_late_cancellations_deleted_at (deleted_at),
+				INDEX idx_late_cancellations_chat_id (chat_id),
+				UNIQUE INDEX uix_late_cancellations_vote_id_user_id (vote_id, user_id)
+			)`,
+		),
+		Down: execAll(
+			"DROP TABLE IF EXISTS late_cancellations",
+			"ALTER TABLE players DROP COLUMN balance",
+		),
+	},
+	{
+		Version: 14,
+		Name:    "create_rides",
+		Up: execAll(
+			`CREATE TABLE rides (
+				id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+				created_at TIMESTAMP NULL,
+				updated_at TIMESTAMP NULL,
+				deleted_at TIMESTAMP NULL,
+				vote_id INT NOT NULL,
+				user_id INT NOT NULL,
+				player_id INT UNSIGNED NULL,
+				seats INT NOT NULL DEFAULT 0,
+				PRIMARY KEY (id),
+				INDEX idx_rides_deleted_at (deleted_at),
+				UNIQUE INDEX uix_rides_vote_id_user_id (vote_id, user_id)
+			)`,
+		),
+		Down: execAll(
+			"DROP TABLE IF EXISTS rides",
+		),
+	},
+	{
+		Version: 15,
+		Name:    "create_duties",
+		Up: execAll(
+			`CREATE TABLE duties (
+				id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+				created_at TIMESTAMP NULL,
+				updated_at TIMESTAMP NULL,
+				deleted_at TIMESTAMP NULL,
+				chat_id BIGINT NOT NULL,
+				vote_id INT NOT NULL,
+				name VARCHAR(64) NOT NULL,
+				user_id INT NOT NULL,
+				player_id INT UNSIGNED NULL,
+				passed VARCHAR(255) NOT NULL DEFAULT '',
+				PRIMARY KEY (id),
+				INDEX idx_duties_deleted_at (deleted_at),
+				INDEX idx_duties_chat_id (chat_id),
+				UNIQUE INDEX uix_duties_vote_id_name (vote_id, name)
+			)`,
+		),
+		Down: execAll(
+			"DROP TABLE IF EXISTS duties",
+		),
+	},
+	{
+		Version: 16,
+		Name:    "create_polls",
+		Up: execAll(
+			`CREATE TABLE polls (
+				id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+				created_at TIMESTAMP NULL,
+				updated_at TIMESTAMP NULL,
+				deleted_at TIMESTAMP NULL,
+				chat_id BIGINT NOT NULL,
+				message_id INT NOT NULL DEFAULT 0,
+				closed TINYINT(1) NOT NULL DEFAULT 0,
+				promoted INT UNSIGNED NOT NULL DEFAULT 0,
+				PRIMARY KEY (id),
+				INDEX idx_polls_deleted_at (deleted_at),
+				INDEX idx_polls_chat_id (chat_id)
+			)`,
+			`CREATE TABLE poll_slots (
+				id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+				created_at TIMESTAMP NULL,
+				updated_at TIMESTAMP NULL,
+				deleted_at TIMESTAMP NULL,
+				poll_id INT UNSIGNED NOT NULL,
+				date TIMESTAMP NULL,
+				PRIMARY KEY (id),
+				INDEX idx_poll_slots_deleted_at (deleted_at),
+				INDEX idx_poll_slots_poll_id (poll_id)
+			)`,
+			`CREATE TABLE poll_answers (
+				id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+				created_at TIMESTAMP NULL,
+				updated_at TIMESTAMP NULL,
+				deleted_at TIMESTAMP NULL,
+				slot_id INT UNSIGNED NOT NULL,
+				user_id INT NOT NULL,
+				player_id INT UNSIGNED NULL,
+				PRIMARY KEY (id),
+				INDEX idx_poll_answers_deleted_at (deleted_at),
+				UNIQUE INDEX uix_poll_answers_slot_id_user_id (slot_id, user_id)
+			)`,
+			"ALTER TABLE games ADD COLUMN special TINYINT(1) NOT NULL DEFAULT 0",
+		),
+		Down: execAll(
+			"ALTER TABLE games DROP COLUMN special",
+			"DROP TABLE IF EXISTS poll_answers",
+			"DROP TABLE IF EXISTS poll_slots",
+			"DROP TABLE IF EXISTS polls",
+		),
+	},
+	{
+		Version: 17,
+		Name:    "add_votes_voted_at",
+		// voters are queued by the time of their answer, updated_at moves on any change.
+		Up: execAll(
+			"ALTER TABLE votes ADD COLUMN voted_at TIMESTAMP NULL",
+			"UPDATE votes SET voted_at = updated_at",
+		),
+		Down: execAll(
+			"ALTER TABLE votes DROP COLUMN voted_at",
+		),
+	},
+}
@@ -0,0 +1,245 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// Player - telegram user playing in chat, names are kept as of the last button press.
+type Player struct {
+	gorm.Model
+
+	ChatID    int64  `json:"chat_id"`
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	// Nickname - name the player chose for the chat, empty if not set.
+	Nickname string `json:"nickname"`
+	// Balance - money of the player in the chat, negative if the player owes.
+	Balance int `json:"balance"`
+}
+
+// Name - full telegram name of player.
+func (p Player) Name() string {
+	return strings.TrimSpace(p.FirstName + " " + p.LastName)
+}
+
+// GetPlayers - return all players of chat.
+func (e *Engine) GetPlayers(chatID int64) ([]Player, error) {
+	var players []Player
+
+	if err := e.Where(Player{ChatID: chatID}).Find(&players).Error; err != nil {
+		return nil, errors.Wrapf(err, "cannot get players of chat %d", chatID)
+	}
+
+	return players, nil
+}
+
+// GetPlayer - return player of user in chat, nil if there is none.
+func (e *Engine) GetPlayer(chatID int64, userID int) (*Player, error) {
+	var player Player
+
+	err := e.Where(Player{ChatID: chatID, UserID: userID}).Take(&player).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get player of user %d", userID)
+	}
+
+	return &player, nil
+}
+
+// SavePlayer - create player of user in chat or update its names, `p` gets the stored player.
+// Players backfilled from old votes have no chat, the first chat they are seen in adopts them.
+func (e *Engine) SavePlayer(p *Player) error {
+	player, err := e.adoptPlayer(p.ChatID, p.UserID)
+	if err != nil {
+		return err
+	}
+
+	player.Username, player.FirstName, player.LastName = p.Username, p.FirstName, p.LastName
+	if err := e.Save(&player).Error; err != nil {
+		return errors.Wrapf(err, "cannot save player of user %d", p.UserID)
+	}
+	*p = player
+
+	return nil
+}
+
+// SetNickname - set nickname of user in chat, the player is created if it is not known yet.
+func (e *Engine) SetNickname(chatID int64, userID int, nickname string) error {
+	player, err := e.adoptPlayer(chatID, userID)
+	if err != nil {
+		return err
+	}
+
+	player.Nickname = nickname
+	if err := e.Save(&player).Error; err != nil {
+		return errors.Wrapf(err, "cannot set nickname of user %d", userID)
+	}
+
+	return nil
+}
+
+// adoptPlayer - return player of user in chat, the player backfilled without chat
+// moved to the chat or a new one if there is neither. The player is not saved.
+func (e *Engine) adoptPlayer(chatID int64, userID int) (Player, error) {
+	var player Player
+
+	err := e.Where(Player{ChatID: chatID, UserID: userID}).Take(&player).Error
+	if gorm.IsRecordNotFoundError(err) {
+		err = e.Where("chat_id = 0 AND user_id = ?", userID).Take(&player).Error
+	}
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return player, errors.Wrapf(err, "cannot get player of user %d", userID)
+	}
+
+	player.ChatID, player.UserID = chatID, userID
+
+	return player, nil
+}
+
+// AddBalance - add `amount` to balance of user in chat, negative amount charges the player.
+func (e *Engine) AddBalance(chatID int64, userID int, amount int) error {
+	res := e.Model(&Player{}).
+		Where(Player{ChatID: chatID, UserID: userID}).
+		UpdateColumn("balance", gorm.Expr("balance + ?", amount))
+	if res.Error != nil {
+		return errors.Wrapf(res.Error, "cannot change balance of user %d", userID)
+	}
+	if res.RowsAffected == 0 {
+		return errors.Errorf("user %d is not a player of chat %d", userID, chatID)
+	}
+
+	return nil
+}
+
+// GetPlayers - return all players of chat.
+func (s *MemoryStore) GetPlayers(chatID int64) ([]Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var players []Player
+	for _, p := range s.players {
+		if p.ChatID == chatID {
+			players = append(players, p)
+		}
+	}
+
+	return players, nil
+}
+
+// GetPlayer - return player of user in chat, nil if there is none.
+func (s *MemoryStore) GetPlayer(chatID int64, userID int) (*Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.players {
+		if p.ChatID == chatID && p.UserID == userID {
+			player := p
+			return &player, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// SavePlayer - create player of user in chat or update its names, `p` gets the stored player.
+// Players backfilled from old votes have no chat, the first chat they are seen in adopts them.
+func (s *MemoryStore) SavePlayer(p *Player) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.savePlayer(p)
+
+	return nil
+}
+
+// savePlayer - create or update player, must be called with the lock held.
+func (s *MemoryStore) savePlayer(p *Player) {
+	if i := s.adoptPlayer(p.ChatID, p.UserID); i >= 0 {
+		old := s.players[i]
+		old.Username, old.FirstName, old.LastName = p.Username, p.FirstName, p.LastName
+		old.UpdatedAt = time.Now()
+		s.players[i] = old
+		*p = old
+		return
+	}
+
+	p.ID, p.CreatedAt = s.nextID()
+	p.UpdatedAt = p.CreatedAt
+	s.players = append(s.players, *p)
+}
+
+// SetNickname - set nickname of user in chat, the player is created if it is not known yet.
+func (s *MemoryStore) SetNickname(chatID int64, userID int, nickname string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.adoptPlayer(chatID, userID); i >= 0 {
+		s.players[i].Nickname = nickname
+		s.players[i].UpdatedAt = time.Now()
+		return nil
+	}
+
+	p := Player{ChatID: chatID, UserID: userID, Nickname: nickname}
+	p.ID, p.CreatedAt = s.nextID()
+	p.UpdatedAt = p.CreatedAt
+	s.players = append(s.players, p)
+
+	return nil
+}
+
+// AddBalance - add `amount` to balance of user in chat, negative amount charges the player.
+func (s *MemoryStore) AddBalance(chatID int64, userID int, amount int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, p := range s.players {
+		if p.ChatID == chatID && p.UserID == userID {
+			s.players[i].Balance += amount
+			return nil
+		}
+	}
+
+	return errors.Errorf("user %d is not a player of chat %d", userID, chatID)
+}
+
+// adoptPlayer - index of player of user in chat, the player backfilled without chat
+// is moved to the chat, -1 if there is neither. Must be called with the lock held.
+func (s *MemoryStore) adoptPlayer(chatID int64, userID int) int {
+	backfilled := -1
+	for i, p := range s.players {
+		if p.UserID != userID {
+			continue
+		}
+		if p.ChatID == chatID {
+			return i
+		}
+		if p.ChatID == 0 {
+			backfilled = i
+		}
+	}
+
+	if backfilled >= 0 {
+		s.players[backfilled].ChatID = chatID
+	}
+
+	return backfilled
+}
+
+// player - return player by id, must be called with the lock held.
+func (s *MemoryStore) player(id uint) Player {
+	for _, p := range s.players {
+		if p.ID == id {
+			return p
+		}
+	}
+
+	return Player{}
+}
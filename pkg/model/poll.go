@@ -0,0 +1,238 @@
+package model
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// Poll - availability poll of chat over candidate game times.
+type Poll struct {
+	gorm.Model
+
+	ChatID int64 `json:"chat_id"`
+	// MessageID - telegram message of the poll in chat.
+	MessageID int  `json:"message_id"`
+	Closed    bool `json:"closed"`
+	// Promoted - slot that became the game, 0 if none.
+	Promoted uint `json:"promoted"`
+
+	// Slots - candidate times in order, loaded with the poll.
+	Slots []PollSlot `json:"slots" gorm:"association_autoupdate:false;association_autocreate:false"`
+}
+
+// PollSlot - candidate game time of poll with players who can make it.
+type PollSlot struct {
+	gorm.Model
+
+	PollID uint      `json:"poll_id"`
+	Date   time.Time `json:"date"`
+
+	// Answers - players who can make it, loaded with the poll.
+	Answers []PollAnswer `json:"answers" gorm:"foreignkey:SlotID;association_autoupdate:false;association_autocreate:false"`
+}
+
+// PollAnswer - player who can make the slot.
+type PollAnswer struct {
+	gorm.Model
+
+	SlotID   uint `json:"slot_id"`
+	UserID   int  `json:"user_id"`
+	PlayerID uint `json:"player_id"`
+}
+
+// CreatePoll - create poll of chat with slots at `dates`, `p` gets the stored poll.
+func (e *Engine) CreatePoll(p *Poll, dates []time.Time) error {
+	tx := e.Begin()
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "cannot begin poll transaction")
+	}
+
+	if err := tx.Create(p).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrapf(err, "cannot create poll of chat %d", p.ChatID)
+	}
+
+	p.Slots = nil
+	for _, d := range dates {
+		slot := PollSlot{PollID: p.ID, Date: d}
+		if err := tx.Create(&slot).Error; err != nil {
+			tx.Rollback()
+			return errors.Wrapf(err, "cannot create slot of poll %d", p.ID)
+		}
+		p.Slots = append(p.Slots, slot)
+	}
+
+	return tx.Commit().Error
+}
+
+// GetPoll - return poll by `id` with its slots and answers.
+func (e *Engine) GetPoll(id uint) (*Poll, error) {
+	var poll Poll
+
+	err := e.Preload("Slots", func(db *gorm.DB) *gorm.DB { return db.Order("date") }).
+		Preload("Slots.Answers", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Take(&poll, id).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get poll %d", id)
+	}
+
+	return &poll, nil
+}
+
+// GetLastPoll - return the latest open poll of chat, nil if there is none.
+func (e *Engine) GetLastPoll(chatID int64) (*Poll, error) {
+	var poll Poll
+
+	err := e.Where("chat_id = ? AND closed = ?", chatID, false).Order("id DESC").Take(&poll).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get poll of chat %d", chatID)
+	}
+
+	return e.GetPoll(poll.ID)
+}
+
+// SavePoll - update message and state of poll.
+func (e *Engine) SavePoll(p *Poll) error {
+	err := e.Model(&Poll{}).Where("id = ?", p.ID).
+		Updates(map[string]interface{}{"message_id": p.MessageID, "closed": p.Closed, "promoted": p.Promoted}).Error
+	if err != nil {
+		return errors.Wrapf(err, "cannot save poll %d", p.ID)
+	}
+
+	return nil
+}
+
+// TogglePollAnswer - mark user as able to make the slot or unmark them,
+// report whether the user is marked now.
+func (e *Engine) TogglePollAnswer(slotID uint, userID int, playerID uint) (bool, error) {
+	var answer PollAnswer
+
+	err := e.Where(PollAnswer{SlotID: slotID, UserID: userID}).Take(&answer).Error
+	if gorm.IsRecordNotFoundError(err) {
+		answer = PollAnswer{SlotID: slotID, UserID: userID, PlayerID: playerID}
+		if err := e.Create(&answer).Error; err != nil {
+			return false, errors.Wrapf(err, "cannot answer poll slot %d", slotID)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "cannot get answer of poll slot %d", slotID)
+	}
+
+	// hard delete to keep the answer unique.
+	if err := e.Unscoped().Delete(&answer).Error; err != nil {
+		return false, errors.Wrapf(err, "cannot remove answer of poll slot %d", slotID)
+	}
+
+	return false, nil
+}
+
+// CreatePoll - create poll of chat with slots at `dates`, `p` gets the stored poll.
+func (s *MemoryStore) CreatePoll(p *Poll, dates []time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p.ID, p.CreatedAt = s.nextID()
+	p.UpdatedAt = p.CreatedAt
+	p.Slots = nil
+	for _, d := range dates {
+		slot := PollSlot{PollID: p.ID, Date: d}
+		slot.ID, slot.CreatedAt = s.nextID()
+		slot.UpdatedAt = slot.CreatedAt
+		p.Slots = append(p.Slots, slot)
+	}
+	s.polls = append(s.polls, *p)
+
+	return nil
+}
+
+// GetPoll - return poll by `id` with its slots and answers.
+func (s *MemoryStore) GetPoll(id uint) (*Poll, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.poll(id)
+}
+
+// GetLastPoll - return the latest open poll of chat, nil if there is none.
+func (s *MemoryStore) GetLastPoll(chatID int64) (*Poll, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.polls) - 1; i >= 0; i-- {
+		if p := s.polls[i]; p.ChatID == chatID && !p.Closed {
+			return s.poll(p.ID)
+		}
+	}
+
+	return nil, nil
+}
+
+// SavePoll - update message and state of poll.
+func (s *MemoryStore) SavePoll(p *Poll) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, old := range s.polls {
+		if old.ID == p.ID {
+			s.polls[i].MessageID, s.polls[i].Closed, s.polls[i].Promoted = p.MessageID, p.Closed, p.Promoted
+			s.polls[i].UpdatedAt = time.Now()
+			return nil
+		}
+	}
+
+	return errors.Errorf("cannot save poll %d: not found", p.ID)
+}
+
+// TogglePollAnswer - mark user as able to make the slot or unmark them,
+// report whether the user is marked now.
+func (s *MemoryStore) TogglePollAnswer(slotID uint, userID int, playerID uint) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, a := range s.answers {
+		if a.SlotID == slotID && a.UserID == userID {
+			s.answers = append(s.answers[:i], s.answers[i+1:]...)
+			return false, nil
+		}
+	}
+
+	a := PollAnswer{SlotID: slotID, UserID: userID, PlayerID: playerID}
+	a.ID, a.CreatedAt = s.nextID()
+	a.UpdatedAt = a.CreatedAt
+	s.answers = append(s.answers, a)
+
+	return true, nil
+}
+
+// poll - return poll by id with slots and answers, must be called with the lock held.
+func (s *MemoryStore) poll(id uint) (*Poll, error) {
+	for _, p := range s.polls {
+		if p.ID != id {
+			continue
+		}
+
+		poll := p
+		poll.Slots = make([]PollSlot, len(p.Slots))
+		copy(poll.Slots, p.Slots)
+		sort.SliceStable(poll.Slots, func(i, j int) bool { return poll.Slots[i].Date.Before(poll.Slots[j].Date) })
+		for i := range poll.Slots {
+			poll.Slots[i].Answers = nil
+			for _, a := range s.answers {
+				if a.SlotID == poll.Slots[i].ID {
+					poll.Slots[i].Answers = append(poll.Slots[i].Answers, a)
+				}
+			}
+		}
+
+		return &poll, nil
+	}
+
+	return nil, errors.Wrapf(gorm.ErrRecordNotFound, "cannot get poll %d", id)
+}
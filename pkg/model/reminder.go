@@ -1,6 +1,9 @@
 package model
 
-import "github.com/jinzhu/gorm"
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
 
 // Reminder - model that represents remiders fot vote.
 type Reminder struct {
@@ -8,23 +11,61 @@ type Reminder struct {
 
 	ReminderID int `json:"reminder_id"`
 	VoteID     int `json:"vote_id"`
-	Vote       Vote
+	// Kind - what was sent, every kind is sent once per vote.
+	Kind string `json:"kind"`
+	Vote Vote
 }
 
-// GetReminderByVoteID - retrieves Reminder for passed voteID.
-func GetReminderByVoteID(voteID int) *Reminder {
-	var rem *Reminder
+// GetReminderByVoteID - retrieves Reminder of `kind` for passed voteID, nil if there is none.
+func (e *Engine) GetReminderByVoteID(voteID int, kind string) (*Reminder, error) {
+	var rem Reminder
 
-	db.Take(rem, voteID)
+	err := e.Where(Reminder{VoteID: voteID, Kind: kind}).Take(&rem).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get %s reminder of vote %d", kind, voteID)
+	}
 
-	return rem
+	return &rem, nil
 }
 
-// CreateReminder - creates new reminder for passed voteID.
-func CreateReminder(voteID int) *Reminder {
-	var reminder = &Reminder{VoteID: voteID}
+// CreateReminder - creates new reminder of `kind` for passed voteID.
+func (e *Engine) CreateReminder(voteID int, kind string) (*Reminder, error) {
+	var reminder = &Reminder{VoteID: voteID, Kind: kind}
 
-	db.Create(reminder)
+	if err := e.Create(reminder).Error; err != nil {
+		return nil, errors.Wrapf(err, "cannot create %s reminder of vote %d", kind, voteID)
+	}
 
-	return reminder
+	return reminder, nil
+}
+
+// GetReminderByVoteID - retrieves Reminder of `kind` for passed voteID, nil if there is none.
+func (s *MemoryStore) GetReminderByVoteID(voteID int, kind string) (*Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.reminders {
+		if r.VoteID == voteID && r.Kind == kind {
+			rem := r
+			return &rem, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// CreateReminder - creates new reminder of `kind` for passed voteID.
+func (s *MemoryStore) CreateReminder(voteID int, kind string) (*Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rem := Reminder{VoteID: voteID, Kind: kind}
+	rem.ID, rem.CreatedAt = s.nextID()
+	rem.UpdatedAt = rem.CreatedAt
+	s.reminders = append(s.reminders, rem)
+
+	return &rem, nil
 }
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// Ride - player driving to the game or looking for a lift in vote.
+type Ride struct {
+	gorm.Model
+
+	VoteID   int  `json:"vote_id"`
+	UserID   int  `json:"user_id"`
+	PlayerID uint `json:"player_id"`
+	// Seats - free seats of the driver, 0 if the player needs a ride.
+	Seats int `json:"seats"`
+}
+
+// Driver - report whether the player offers seats.
+func (r Ride) Driver() bool {
+	return r.Seats > 0
+}
+
+// GetRides - return rides of vote `voteID` in order they were offered.
+func (e *Engine) GetRides(voteID int) ([]Ride, error) {
+	var rides []Ride
+
+	if err := e.Where(Ride{VoteID: voteID}).Order("id").Find(&rides).Error; err != nil {
+		return nil, errors.Wrapf(err, "cannot get rides of vote %d", voteID)
+	}
+
+	return rides, nil
+}
+
+// SaveRide - create ride of user in vote or update its seats.
+func (e *Engine) SaveRide(r *Ride) error {
+	var ride Ride
+
+	// map keeps zero seats of a driver turned passenger.
+	err := e.Where(Ride{VoteID: r.VoteID, UserID: r.UserID}).
+		Assign(map[string]interface{}{"player_id": r.PlayerID, "seats": r.Seats}).
+		FirstOrCreate(&ride).Error
+	if err != nil {
+		return errors.Wrapf(err, "cannot save ride of user %d", r.UserID)
+	}
+	*r = ride
+
+	return nil
+}
+
+// DeleteRide - remove ride of user from vote `voteID`.
+func (e *Engine) DeleteRide(voteID, userID int) error {
+	// hard delete to keep unique index free for the next offer.
+	if err := e.Unscoped().Where(Ride{VoteID: voteID, UserID: userID}).Delete(&Ride{}).Error; err != nil {
+		return errors.Wrapf(err, "cannot delete ride of user %d", userID)
+	}
+
+	return nil
+}
+
+// GetRides - return rides of vote `voteID` in order they were offered.
+func (s *MemoryStore) GetRides(voteID int) ([]Ride, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rides []Ride
+	for _, r := range s.rides {
+		if r.VoteID == voteID {
+			rides = append(rides, r)
+		}
+	}
+
+	return rides, nil
+}
+
+// SaveRide - create ride of user in vote or update its seats.
+func (s *MemoryStore) SaveRide(r *Ride) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, old := range s.rides {
+		if old.VoteID == r.VoteID && old.UserID == r.UserID {
+			old.PlayerID, old.Seats = r.PlayerID, r.Seats
+			s.rides[i] = old
+			*r = old
+			return nil
+		}
+	}
+
+	r.ID, r.CreatedAt = s.nextID()
+	r.UpdatedAt = r.CreatedAt
+	s.rides = append(s.rides, *r)
+
+	return nil
+}
+
+// DeleteRide - remove ride of user from vote `voteID`.
+func (s *MemoryStore) DeleteRide(voteID, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, r := range s.rides {
+		if r.VoteID == voteID && r.UserID == userID {
+			s.rides = append(s.rides[:i], s.rides[i+1:]...)
+			return nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// Role - role of telegram user in chat.
+type Role struct {
+	gorm.Model
+
+	ChatID int64  `json:"chat_id"`
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+}
+
+// GetRoles - return all roles granted in chat.
+func (e *Engine) GetRoles(chatID int64) ([]Role, error) {
+	var roles []Role
+
+	if err := e.Where(Role{ChatID: chatID}).Find(&roles).Error; err != nil {
+		return nil, errors.Wrapf(err, "cannot get roles of chat %d", chatID)
+	}
+
+	return roles, nil
+}
+
+// GetRole - return role of user in chat, nil if there is none.
+func (e *Engine) GetRole(chatID int64, userID int) (*Role, error) {
+	var role Role
+
+	err := e.Where(Role{ChatID: chatID, UserID: userID}).Take(&role).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get role of user %d", userID)
+	}
+
+	return &role, nil
+}
+
+// SetRole - grant role to user in chat replacing the previous one.
+func (e *Engine) SetRole(r Role) error {
+	var role Role
+
+	err := e.Where(Role{ChatID: r.ChatID, UserID: r.UserID}).
+		Assign(Role{Name: r.Name, Role: r.Role}).
+		FirstOrCreate(&role).Error
+	if err != nil {
+		return errors.Wrapf(err, "cannot set role of user %d", r.UserID)
+	}
+
+	return nil
+}
+
+// DeleteRole - revoke role of user in chat.
+func (e *Engine) DeleteRole(chatID int64, userID int) error {
+	// hard delete to keep unique index free for the next grant.
+	err := e.Unscoped().Where(Role{ChatID: chatID, UserID: userID}).Delete(&Role{}).Error
+	if err != nil {
+		return errors.Wrapf(err, "cannot delete role of user %d", userID)
+	}
+
+	return nil
+}
+
+// GetRoles - return all roles granted in chat.
+func (s *MemoryStore) GetRoles(chatID int64) ([]Role, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var roles []Role
+	for _, r := range s.roles {
+		if r.ChatID == chatID {
+			roles = append(roles, r)
+		}
+	}
+
+	return roles, nil
+}
+
+// GetRole - return role of user in chat, nil if there is none.
+func (s *MemoryStore) GetRole(chatID int64, userID int) (*Role, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.roles {
+		if r.ChatID == chatID && r.UserID == userID {
+			role := r
+			return &role, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// SetRole - grant role to user in chat replacing the previous one.
+func (s *MemoryStore) SetRole(r Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, old := range s.roles {
+		if old.ChatID == r.ChatID && old.UserID == r.UserID {
+			s.roles[i].Name, s.roles[i].Role = r.Name, r.Role
+			s.roles[i].UpdatedAt = time.Now()
+			return nil
+		}
+	}
+
+	r.ID, r.CreatedAt = s.nextID()
+	r.UpdatedAt = r.CreatedAt
+	s.roles = append(s.roles, r)
+
+	return nil
+}
+
+// DeleteRole - revoke role of user in chat.
+func (s *MemoryStore) DeleteRole(chatID int64, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, r := range s.roles {
+		if r.ChatID == chatID && r.UserID == userID {
+			s.roles = append(s.roles[:i], s.roles[i+1:]...)
+			return nil
+		}
+	}
+
+	return nil
+}
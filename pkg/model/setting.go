@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// Setting - per chat setting changed at runtime.
+type Setting struct {
+	gorm.Model
+
+	ChatID int64  `json:"chat_id"`
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+}
+
+// GetSettings - return all settings of chat.
+func (e *Engine) GetSettings(chatID int64) ([]Setting, error) {
+	var settings []Setting
+
+	if err := e.Where(Setting{ChatID: chatID}).Find(&settings).Error; err != nil {
+		return nil, errors.Wrapf(err, "cannot get settings of chat %d", chatID)
+	}
+
+	return settings, nil
+}
+
+// SetSetting - create or update chat setting.
+func (e *Engine) SetSetting(chatID int64, key, value string) error {
+	var setting Setting
+
+	err := e.Where(Setting{ChatID: chatID, Key: key}).
+		Assign(Setting{Value: value}).
+		FirstOrCreate(&setting).Error
+	if err != nil {
+		return errors.Wrapf(err, "cannot set %s of chat %d", key, chatID)
+	}
+
+	return nil
+}
+
+// GetSettings - return all settings of chat.
+func (s *MemoryStore) GetSettings(chatID int64) ([]Setting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var settings []Setting
+	for _, st := range s.settings {
+		if st.ChatID == chatID {
+			settings = append(settings, st)
+		}
+	}
+
+	return settings, nil
+}
+
+// SetSetting - create or update chat setting.
+func (s *MemoryStore) SetSetting(chatID int64, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, st := range s.settings {
+		if st.ChatID == chatID && st.Key == key {
+			s.settings[i].Value = value
+			s.settings[i].UpdatedAt = time.Now()
+			return nil
+		}
+	}
+
+	st := Setting{ChatID: chatID, Key: key, Value: value}
+	st.ID, st.CreatedAt = s.nextID()
+	st.UpdatedAt = st.CreatedAt
+	s.settings = append(s.settings, st)
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package model
+
+import "time"
+
+// Store - persistence of the bot entities.
+type Store interface {
+	VoteStore
+	ReminderStore
+	SettingStore
+	RoleStore
+	SubscriptionStore
+	PlayerStore
+	GameStore
+	AuditStore
+	CancellationStore
+	RideStore
+	DutyStore
+	PollStore
+}
+
+// VoteStore - persistence of votes.
+type VoteStore interface {
+	GetVotes() ([]Vote, error)
+	GetVoteResult(voteID int) ([]VoteResult, error)
+	GetVotesByVoteID(voteID int) ([]Vote, error)
+	GetVote(id int) (Vote, error)
+	CreateVote(v *Vote) (Vote, error)
+	UpdateVote(id int, v Vote) error
+	DeleteVote(voteID, userID int) error
+	SetVoteReason(voteID, userID int, reason string) error
+}
+
+// ReminderStore - persistence of reminders.
+type ReminderStore interface {
+	GetReminderByVoteID(voteID int, kind string) (*Reminder, error)
+	CreateReminder(voteID int, kind string) (*Reminder, error)
+}
+
+// SettingStore - persistence of per chat settings.
+type SettingStore interface {
+	GetSettings(chatID int64) ([]Setting, error)
+	SetSetting(chatID int64, key, value string) error
+}
+
+// RoleStore - persistence of user roles.
+type RoleStore interface {
+	GetRoles(chatID int64) ([]Role, error)
+	GetRole(chatID int64, userID int) (*Role, error)
+	SetRole(r Role) error
+	DeleteRole(chatID int64, userID int) error
+}
+
+// SubscriptionStore - persistence of direct message subscriptions.
+type SubscriptionStore interface {
+	GetSubscriptions(chatID int64) ([]Subscription, error)
+	GetSubscription(chatID int64, userID int) (*Subscription, error)
+	SaveSubscription(s Subscription) error
+	DeleteSubscription(chatID int64, userID int) error
+}
+
+// PlayerStore - persistence of players.
+type PlayerStore interface {
+	GetPlayers(chatID int64) ([]Player, error)
+	GetPlayer(chatID int64, userID int) (*Player, error)
+	SavePlayer(p *Player) error
+	SetNickname(chatID int64, userID int, nickname string) error
+	AddBalance(chatID int64, userID int, amount int) error
+}
+
+// GameStore - persistence of games.
+type GameStore interface {
+	GetGames(chatID int64, from, to time.Time) ([]Game, error)
+	GetGame(chatID int64, voteID int) (*Game, error)
+	SaveGame(g *Game) error
+	ImportGames(chatID int64, games []ImportedGame) error
+}
+
+// AuditStore - persistence of vote changes.
+type AuditStore interface {
+	AddVoteAudit(a *VoteAudit) error
+	GetVoteAudits(voteID int) ([]VoteAudit, error)
+}
+
+// CancellationStore - persistence of late cancellations.
+type CancellationStore interface {
+	GetLateCancellations(chatID int64) ([]LateCancellation, error)
+	GetLateCancellation(voteID, userID int) (*LateCancellation, error)
+	AddLateCancellation(c *LateCancellation) error
+}
+
+// RideStore - persistence of carpool offers and requests.
+type RideStore interface {
+	GetRides(voteID int) ([]Ride, error)
+	SaveRide(r *Ride) error
+	DeleteRide(voteID, userID int) error
+}
+
+// DutyStore - persistence of equipment duties.
+type DutyStore interface {
+	GetDuties(chatID int64) ([]Duty, error)
+	GetVoteDuties(voteID int) ([]Duty, error)
+	SaveDuty(d *Duty) error
+}
+
+// PollStore - persistence of availability polls.
+type PollStore interface {
+	CreatePoll(p *Poll, dates []time.Time) error
+	GetPoll(id uint) (*Poll, error)
+	GetLastPoll(chatID int64) (*Poll, error)
+	SavePoll(p *Poll) error
+	TogglePollAnswer(slotID uint, userID int, playerID uint) (bool, error)
+}
+
+var (
+	_ Store = (*Engine)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
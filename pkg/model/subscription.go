@@ -0,0 +1,151 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// Subscription - direct message notifications a user opted in for about votes of chat.
+type Subscription struct {
+	gorm.Model
+
+	ChatID int64 `json:"chat_id"`
+	UserID int   `json:"user_id"`
+
+	VoteOpen     bool `json:"vote_open"`
+	GameTomorrow bool `json:"game_tomorrow"`
+	Promoted     bool `json:"promoted"`
+	Nudge        bool `json:"nudge"`
+
+	// QuietFrom and QuietTo - hours of the chat timezone notifications are
+	// held back in, quiet hours are disabled if they are equal.
+	QuietFrom int `json:"quiet_from"`
+	QuietTo   int `json:"quiet_to"`
+}
+
+// GetSubscriptions - return all subscriptions to chat.
+func (e *Engine) GetSubscriptions(chatID int64) ([]Subscription, error) {
+	var subs []Subscription
+
+	if err := e.Where(Subscription{ChatID: chatID}).Find(&subs).Error; err != nil {
+		return nil, errors.Wrapf(err, "cannot get subscriptions of chat %d", chatID)
+	}
+
+	return subs, nil
+}
+
+// GetSubscription - return subscription of user to chat, nil if there is none.
+func (e *Engine) GetSubscription(chatID int64, userID int) (*Subscription, error) {
+	var sub Subscription
+
+	err := e.Where(Subscription{ChatID: chatID, UserID: userID}).Take(&sub).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get subscription of user %d", userID)
+	}
+
+	return &sub, nil
+}
+
+// SaveSubscription - create or replace subscription of user to chat.
+func (e *Engine) SaveSubscription(s Subscription) error {
+	var sub Subscription
+
+	// map keeps false flags and zero hours, struct Assign would skip them.
+	err := e.Where(Subscription{ChatID: s.ChatID, UserID: s.UserID}).
+		Assign(map[string]interface{}{
+			"vote_open":     s.VoteOpen,
+			"game_tomorrow": s.GameTomorrow,
+			"promoted":      s.Promoted,
+			"nudge":         s.Nudge,
+			"quiet_from":    s.QuietFrom,
+			"quiet_to":      s.QuietTo,
+		}).
+		FirstOrCreate(&sub).Error
+	if err != nil {
+		return errors.Wrapf(err, "cannot save subscription of user %d", s.UserID)
+	}
+
+	return nil
+}
+
+// DeleteSubscription - remove subscription of user to chat.
+func (e *Engine) DeleteSubscription(chatID int64, userID int) error {
+	// hard delete to keep unique index free for the next subscription.
+	err := e.Unscoped().Where(Subscription{ChatID: chatID, UserID: userID}).Delete(&Subscription{}).Error
+	if err != nil {
+		return errors.Wrapf(err, "cannot delete subscription of user %d", userID)
+	}
+
+	return nil
+}
+
+// GetSubscriptions - return all subscriptions to chat.
+func (s *MemoryStore) GetSubscriptions(chatID int64) ([]Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var subs []Subscription
+	for _, sub := range s.subscriptions {
+		if sub.ChatID == chatID {
+			subs = append(subs, sub)
+		}
+	}
+
+	return subs, nil
+}
+
+// GetSubscription - return subscription of user to chat, nil if there is none.
+func (s *MemoryStore) GetSubscription(chatID int64, userID int) (*Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.subscriptions {
+		if sub.ChatID == chatID && sub.UserID == userID {
+			found := sub
+			return &found, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// SaveSubscription - create or replace subscription of user to chat.
+func (s *MemoryStore) SaveSubscription(sub Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, old := range s.subscriptions {
+		if old.ChatID == sub.ChatID && old.UserID == sub.UserID {
+			sub.Model = old.Model
+			sub.UpdatedAt = time.Now()
+			s.subscriptions[i] = sub
+			return nil
+		}
+	}
+
+	sub.ID, sub.CreatedAt = s.nextID()
+	sub.UpdatedAt = sub.CreatedAt
+	s.subscriptions = append(s.subscriptions, sub)
+
+	return nil
+}
+
+// DeleteSubscription - remove subscription of user to chat.
+func (s *MemoryStore) DeleteSubscription(chatID int64, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, sub := range s.subscriptions {
+		if sub.ChatID == chatID && sub.UserID == userID {
+			s.subscriptions = append(s.subscriptions[:i], s.subscriptions[i+1:]...)
+			return nil
+		}
+	}
+
+	return nil
+}
@@ -1,7 +1,10 @@
 package model
 
 import (
+	"time"
+
 	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
 )
 
 // Vote - model for user collection.
@@ -10,75 +13,324 @@ type Vote struct {
 
 	VoteID     int    `json:"vote_id"`
 	UserID     int    `json:"user_id"`
-	VoterName  string `json:"voter_name"`
-	FirstName  string `json:"voter_first_name"`
-	LastName   string `json:"voter_last_name"`
+	PlayerID   uint   `json:"player_id"`
 	PressedBtn string `json:"pressed_btn"`
+	// ProxyBy - user id of admin who voted on behalf of the player, 0 if the player voted.
+	ProxyBy int `json:"proxy_by"`
+	// Reason - why the player refused, a preset key or free text.
+	Reason string `json:"reason"`
+	// VotedAt - when the player last changed their answer, voters are queued by it.
+	VotedAt time.Time `json:"voted_at"`
+
+	// Player - voter, loaded with the vote and never saved through it.
+	Player Player `json:"player" gorm:"association_autoupdate:false;association_autocreate:false"`
 }
 
-var db = GetEngine()
+// VoteResult - represent vote results by vote id.
+type VoteResult struct {
+	PressedBtn string
+	Count      int
+}
 
 // GetVotes - return votes list.
-func GetVotes(where ...string) []Vote {
+func (e *Engine) GetVotes() ([]Vote, error) {
 	var votes []Vote
 
-	if where != nil {
-		db.Where(where).Find(&votes)
-	} else {
-		db.Find(&votes)
+	if err := e.Preload("Player").Find(&votes).Error; err != nil {
+		return nil, errors.Wrap(err, "cannot get votes")
 	}
 
-	return votes
-}
-
-// VoteResult - represent vote results by vote id.
-type VoteResult struct {
-	PressedBtn string
-	Count      int
+	return votes, nil
 }
 
 // GetVoteResult - return votes count for vote id.
-func GetVoteResult(voteID int) []VoteResult {
+func (e *Engine) GetVoteResult(voteID int) ([]VoteResult, error) {
 	var res []VoteResult
 
-	db.Model(&Vote{}).
+	err := e.Model(&Vote{}).
 		Where(Vote{VoteID: voteID}).
 		Select("COUNT(pressed_btn) AS count, pressed_btn").
 		Group("pressed_btn").
-		Scan(&res)
+		Scan(&res).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get result of vote %d", voteID)
+	}
 
-	return res
+	return res, nil
 }
 
 // GetVotesByVoteID - return votes by vote id.
-func GetVotesByVoteID(voteID int) []Vote {
+func (e *Engine) GetVotesByVoteID(voteID int) ([]Vote, error) {
 	var votes []Vote
 
-	db.Where(Vote{VoteID: voteID}).Find(&votes)
+	if err := e.Preload("Player").Where(Vote{VoteID: voteID}).Find(&votes).Error; err != nil {
+		return nil, errors.Wrapf(err, "cannot get votes of vote %d", voteID)
+	}
 
-	return votes
+	return votes, nil
 }
 
 // GetVote - return vote by `id`.
-func GetVote(id int) Vote {
+func (e *Engine) GetVote(id int) (Vote, error) {
 	var vote Vote
 
-	db.Take(&vote, id)
+	if err := e.Preload("Player").Take(&vote, id).Error; err != nil {
+		return vote, errors.Wrapf(err, "cannot get vote %d", id)
+	}
 
-	return vote
+	return vote, nil
 }
 
-// CreateVote - create new vote in database.
-func CreateVote(v *Vote) Vote {
-	var vote Vote
-	db.Where(&Vote{VoteID: v.VoteID, UserID: v.UserID}).Assign(v).FirstOrCreate(&vote)
+// CreateVote - create new vote in database or update the one of the same user.
+// VotedAt is set only when the answer changes, imported votes take it from CreatedAt.
+func (e *Engine) CreateVote(v *Vote) (Vote, error) {
+	var old, vote Vote
+
+	err := e.Where(&Vote{VoteID: v.VoteID, UserID: v.UserID}).Take(&old).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return vote, errors.Wrapf(err, "cannot get vote of user %d", v.UserID)
+	}
+
+	// map keeps zero values, a player voting again clears ProxyBy of admin's vote
+	// and the reason of the previous refusal.
+	attrs := map[string]interface{}{"player_id": v.PlayerID, "pressed_btn": v.PressedBtn, "proxy_by": v.ProxyBy, "reason": v.Reason}
+	if at, ok := votedAt(old, v); ok {
+		attrs["voted_at"] = at
+	}
+	if !v.CreatedAt.IsZero() {
+		attrs["created_at"], attrs["updated_at"] = v.CreatedAt, v.UpdatedAt
+	}
+
+	err = e.Where(&Vote{VoteID: v.VoteID, UserID: v.UserID}).Assign(attrs).FirstOrCreate(&vote).Error
+	if err != nil {
+		return vote, errors.Wrap(err, "cannot create vote")
+	}
+
+	return vote, nil
+}
+
+// votedAt - time the answer of vote `v` replacing `old` is given, `ok` is false
+// if the answer is not changed and the voter keeps their place.
+func votedAt(old Vote, v *Vote) (at time.Time, ok bool) {
+	switch {
+	case !v.CreatedAt.IsZero():
+		return v.CreatedAt, true
+	case old.ID == 0 || old.PressedBtn != v.PressedBtn:
+		return time.Now(), true
+	}
+
+	return time.Time{}, false
+}
+
+// DeleteVote - remove vote of user from vote `voteID`.
+func (e *Engine) DeleteVote(voteID, userID int) error {
+	// hard delete to keep unique index free for the next vote.
+	err := e.Unscoped().Where(Vote{VoteID: voteID, UserID: userID}).Delete(&Vote{}).Error
+	if err != nil {
+		return errors.Wrapf(err, "cannot delete vote of user %d", userID)
+	}
+
+	return nil
+}
+
+// SetVoteReason - set reason of user refusal in vote `voteID`.
+func (e *Engine) SetVoteReason(voteID, userID int, reason string) error {
+	// UpdateColumn keeps updated_at, voters are listed in order of their votes.
+	err := e.Model(&Vote{}).Where(Vote{VoteID: voteID, UserID: userID}).UpdateColumn("reason", reason).Error
+	if err != nil {
+		return errors.Wrapf(err, "cannot set reason of user %d", userID)
+	}
+
+	return nil
+}
+
+// UpdateVote - update vote by `id`.
+func (e *Engine) UpdateVote(id int, v Vote) error {
+	vote, err := e.GetVote(id)
+	if err != nil {
+		return err
+	}
+
+	if err := e.Model(&vote).Updates(v).Error; err != nil {
+		return errors.Wrapf(err, "cannot update vote %d", id)
+	}
+
+	return nil
+}
+
+// GetVotes - return votes list.
+func (s *MemoryStore) GetVotes() ([]Vote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	votes := make([]Vote, len(s.votes))
+	for i, v := range s.votes {
+		v.Player = s.player(v.PlayerID)
+		votes[i] = v
+	}
+
+	return votes, nil
+}
+
+// GetVoteResult - return votes count for vote id.
+func (s *MemoryStore) GetVoteResult(voteID int) ([]VoteResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res []VoteResult
+	idx := map[string]int{}
+	for _, v := range s.votes {
+		if v.VoteID != voteID {
+			continue
+		}
+
+		i, ok := idx[v.PressedBtn]
+		if !ok {
+			i = len(res)
+			idx[v.PressedBtn] = i
+			res = append(res, VoteResult{PressedBtn: v.PressedBtn})
+		}
+		res[i].Count++
+	}
+
+	return res, nil
+}
+
+// GetVotesByVoteID - return votes by vote id.
+func (s *MemoryStore) GetVotesByVoteID(voteID int) ([]Vote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var votes []Vote
+	for _, v := range s.votes {
+		if v.VoteID == voteID {
+			v.Player = s.player(v.PlayerID)
+			votes = append(votes, v)
+		}
+	}
+
+	return votes, nil
+}
+
+// GetVote - return vote by `id`.
+func (s *MemoryStore) GetVote(id int) (Vote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, v := range s.votes {
+		if v.ID == uint(id) {
+			v.Player = s.player(v.PlayerID)
+			return v, nil
+		}
+	}
+
+	return Vote{}, errors.Wrapf(gorm.ErrRecordNotFound, "cannot get vote %d", id)
+}
+
+// CreateVote - create new vote or update the one of the same user.
+func (s *MemoryStore) CreateVote(v *Vote) (Vote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createVote(v), nil
+}
+
+// createVote - create or update vote, must be called with the lock held.
+func (s *MemoryStore) createVote(v *Vote) Vote {
+	for i, old := range s.votes {
+		if old.VoteID == v.VoteID && old.UserID == v.UserID {
+			vote := old
+			vote.PlayerID, vote.PressedBtn, vote.ProxyBy, vote.Reason = v.PlayerID, v.PressedBtn, v.ProxyBy, v.Reason
+			if at, ok := votedAt(old, v); ok {
+				vote.VotedAt = at
+			}
+			// like gorm, the vote is touched only if something changed.
+			if vote != old {
+				vote.UpdatedAt = time.Now()
+			}
+			if !v.CreatedAt.IsZero() {
+				vote.CreatedAt, vote.UpdatedAt = v.CreatedAt, v.UpdatedAt
+			}
+			s.votes[i] = vote
+
+			return vote
+		}
+	}
+
+	vote := *v
+	id, now := s.nextID()
+	vote.ID = id
+	// imported votes come with the time of their game.
+	if vote.CreatedAt.IsZero() {
+		vote.CreatedAt = now
+	}
+	if vote.UpdatedAt.IsZero() {
+		vote.UpdatedAt = vote.CreatedAt
+	}
+	vote.VotedAt = vote.CreatedAt
+	s.votes = append(s.votes, vote)
 
 	return vote
 }
 
-// UpdateVote - create new vote in database.
-func UpdateVote(id int, v Vote) {
-	vote := GetVote(id)
+// DeleteVote - remove vote of user from vote `voteID`.
+func (s *MemoryStore) DeleteVote(voteID, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, v := range s.votes {
+		if v.VoteID == voteID && v.UserID == userID {
+			s.votes = append(s.votes[:i], s.votes[i+1:]...)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// SetVoteReason - set reason of user refusal in vote `voteID`.
+func (s *MemoryStore) SetVoteReason(voteID, userID int, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, v := range s.votes {
+		if v.VoteID == voteID && v.UserID == userID {
+			s.votes[i].Reason = reason
+		}
+	}
+
+	return nil
+}
+
+// UpdateVote - update vote by `id`, zero fields of `v` are left untouched.
+func (s *MemoryStore) UpdateVote(id int, v Vote) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, old := range s.votes {
+		if old.ID != uint(id) {
+			continue
+		}
+
+		if v.VoteID != 0 {
+			old.VoteID = v.VoteID
+		}
+		if v.UserID != 0 {
+			old.UserID = v.UserID
+		}
+		if v.PlayerID != 0 {
+			old.PlayerID = v.PlayerID
+		}
+		if v.PressedBtn != "" {
+			old.PressedBtn = v.PressedBtn
+		}
+		if old != s.votes[i] {
+			old.UpdatedAt = time.Now()
+		}
+		s.votes[i] = old
+
+		return nil
+	}
 
-	db.Model(&vote).Updates(v)
+	return errors.Wrapf(gorm.ErrRecordNotFound, "cannot get vote %d", id)
 }
@@ -0,0 +1,337 @@
+package vote
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Direct message notifications players can opt in for.
+const (
+	NotifyVoteOpen     = "vote_open"
+	NotifyGameTomorrow = "game_tomorrow"
+	NotifyPromoted     = "promoted"
+	NotifyNudge        = "nudge"
+)
+
+// NotifyKinds - all notifications in display order.
+var NotifyKinds = []string{NotifyVoteOpen, NotifyGameTomorrow, NotifyPromoted, NotifyNudge}
+
+// reminderGroup - kind of the reminder sent to the group.
+const reminderGroup = "group"
+
+var quietRx = regexp.MustCompile(`^(\d{1,2})-(\d{1,2})$`)
+
+// notification - direct message waiting for delivery.
+type notification struct {
+	userID int
+	key    string
+	// date - game date formatted into the message in the user language.
+	date     time.Time
+	withVote bool
+}
+
+// Subscribe - opt user in for direct messages about votes of chat,
+// all notifications are enabled for a new subscription.
+func (b *Bot) Subscribe(chatID int64, userID int) (sub *model.Subscription, created bool, err error) {
+	sub, err = b.Store.GetSubscription(chatID, userID)
+	if err != nil || sub != nil {
+		return sub, false, err
+	}
+
+	sub = &model.Subscription{ChatID: chatID, UserID: userID, VoteOpen: true, GameTomorrow: true, Promoted: true, Nudge: true}
+	if err := b.Store.SaveSubscription(*sub); err != nil {
+		return nil, false, err
+	}
+
+	return sub, true, nil
+}
+
+// Unsubscribe - stop direct messages to user about votes of chat.
+func (b *Bot) Unsubscribe(chatID int64, userID int) error {
+	return b.Store.DeleteSubscription(chatID, userID)
+}
+
+// ToggleNotification - switch notification `kind` of user subscription.
+func (b *Bot) ToggleNotification(chatID int64, userID int, kind string) (*model.Subscription, error) {
+	sub, err := b.Store.GetSubscription(chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, errors.Errorf("user %d is not subscribed", userID)
+	}
+
+	switch kind {
+	case NotifyVoteOpen:
+		sub.VoteOpen = !sub.VoteOpen
+	case NotifyGameTomorrow:
+		sub.GameTomorrow = !sub.GameTomorrow
+	case NotifyPromoted:
+		sub.Promoted = !sub.Promoted
+	case NotifyNudge:
+		sub.Nudge = !sub.Nudge
+	default:
+		return nil, errors.Errorf("unknown notification %q", kind)
+	}
+
+	return sub, b.Store.SaveSubscription(*sub)
+}
+
+// SetQuietHours - set quiet hours of user subscription from "23-8" like `spec`,
+// "off" disables them.
+func (b *Bot) SetQuietHours(chatID int64, userID int, spec string) error {
+	sub, err := b.Store.GetSubscription(chatID, userID)
+	if err != nil {
+		return err
+	}
+	if sub == nil {
+		return errors.Errorf("user %d is not subscribed", userID)
+	}
+
+	if spec == "off" {
+		sub.QuietFrom, sub.QuietTo = 0, 0
+		return b.Store.SaveSubscription(*sub)
+	}
+
+	matches := quietRx.FindStringSubmatch(spec)
+	if matches == nil {
+		return errors.Errorf("wrong quiet hours %q", spec)
+	}
+	from, _ := strconv.Atoi(matches[1])
+	to, _ := strconv.Atoi(matches[2])
+	if from > 23 || to > 23 {
+		return errors.Errorf("hours of %q must be between 0 and 23", spec)
+	}
+
+	sub.QuietFrom, sub.QuietTo = from, to
+	return b.Store.SaveSubscription(*sub)
+}
+
+// NotifySettings - message with notifications of user subscription
+// and buttons to toggle them, texts are in the language of private chat.
+func (b *Bot) NotifySettings(sub *model.Subscription) (string, *tb.ReplyMarkup) {
+	chatID := int64(sub.UserID)
+
+	text := b.T(chatID, "notify.title") + "\n" + b.T(chatID, "notify.quiet_off")
+	if sub.QuietFrom != sub.QuietTo {
+		text = b.T(chatID, "notify.title") + "\n" + b.T(chatID, "notify.quiet", sub.QuietFrom, sub.QuietTo)
+	}
+
+	var keys [][]tb.InlineButton
+	for _, kind := range NotifyKinds {
+		mark := "❌ "
+		if notifyEnabled(sub, kind) {
+			mark = "✅ "
+		}
+		keys = append(keys, []tb.InlineButton{{
+			Unique: "notify_" + kind,
+			Text:   mark + b.T(chatID, "notify.kind."+kind),
+			Data:   kind,
+		}})
+	}
+
+	return text, &tb.ReplyMarkup{InlineKeyboard: keys}
+}
+
+func (b *Bot) notifyButtonHandler(c *tb.Callback) {
+	if b.Channel == nil {
+		b.Respond(c, &tb.CallbackResponse{Text: b.T(int64(c.Sender.ID), "no_channel")})
+		return
+	}
+
+	sub, err := b.ToggleNotification(b.Channel.ID, c.Sender.ID, c.Data)
+	if err != nil {
+		log.Printf("cannot toggle notification: %s", err)
+		b.Respond(c, &tb.CallbackResponse{Text: b.T(int64(c.Sender.ID), "notify.not_subscribed")})
+		return
+	}
+	b.Respond(c, &tb.CallbackResponse{})
+
+	text, mkp := b.NotifySettings(sub)
+	if _, err := b.Edit(c.Message, text, mkp); err != nil {
+		log.Printf("cannot edit notify settings: %s", err)
+	}
+}
+
+func notifyEnabled(sub *model.Subscription, kind string) bool {
+	switch kind {
+	case NotifyVoteOpen:
+		return sub.VoteOpen
+	case NotifyGameTomorrow:
+		return sub.GameTomorrow
+	case NotifyPromoted:
+		return sub.Promoted
+	case NotifyNudge:
+		return sub.Nudge
+	}
+
+	return false
+}
+
+// quiet - report whether `now` is within quiet hours of subscription in `loc`.
+func quiet(sub *model.Subscription, now time.Time, loc *time.Location) bool {
+	h := now.In(loc).Hour()
+	if sub.QuietFrom < sub.QuietTo {
+		return h >= sub.QuietFrom && h < sub.QuietTo
+	}
+	if sub.QuietFrom > sub.QuietTo {
+		return h >= sub.QuietFrom || h < sub.QuietTo
+	}
+
+	return false
+}
+
+// notify - send message `key` about the current game to subscribers of the channel
+// who enabled notification `kind`, only to `users` if it is not nil.
+// Messages to users in quiet hours wait for FlushNotifications in memory,
+// a restart drops them.
+// Return users the message is sent or queued to.
+func (b *Bot) notify(kind, key string, users map[int]bool, withVote bool) map[int]bool {
+	notified := map[int]bool{}
+	if b.Channel == nil {
+		return notified
+	}
+
+	subs, err := b.Store.GetSubscriptions(b.Channel.ID)
+	if err != nil {
+		log.Printf("cannot get subscriptions: %s", err)
+		return notified
+	}
+
+	now := time.Now()
+	loc := b.channelSettings().Location()
+	game := b.GameDate()
+	for i := range subs {
+		sub := &subs[i]
+		if !notifyEnabled(sub, kind) || (users != nil && !users[sub.UserID]) {
+			continue
+		}
+
+		notified[sub.UserID] = true
+		n := notification{userID: sub.UserID, key: key, date: game, withVote: withVote}
+		if quiet(sub, now, loc) {
+			b.mu.Lock()
+			b.pending = append(b.pending, n)
+			b.mu.Unlock()
+			continue
+		}
+		b.deliver(n)
+	}
+
+	return notified
+}
+
+// FlushNotifications - deliver notifications held back by quiet hours that are over at `now`.
+func (b *Bot) FlushNotifications(now time.Time) {
+	if b.Channel == nil {
+		return
+	}
+	loc := b.channelSettings().Location()
+
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	var keep []notification
+	for _, n := range pending {
+		sub, err := b.Store.GetSubscription(b.Channel.ID, n.userID)
+		if err != nil {
+			log.Printf("cannot get subscription: %s", err)
+			keep = append(keep, n)
+			continue
+		}
+		if sub == nil {
+			continue
+		}
+		if quiet(sub, now, loc) {
+			keep = append(keep, n)
+			continue
+		}
+		b.deliver(n)
+	}
+
+	b.mu.Lock()
+	b.pending = append(keep, b.pending...)
+	b.mu.Unlock()
+}
+
+func (b *Bot) deliver(n notification) {
+	chatID := int64(n.userID)
+	to := &tb.User{ID: n.userID}
+	date := formatDate(n.date, "Monday, 2 January 15:04", b.Language(chatID))
+
+	// users who blocked the bot fail here, that must not stop the others.
+	if _, err := b.Send(to, b.T(chatID, n.key, date)); err != nil {
+		log.Printf("cannot notify user %d: %s", n.userID, err)
+		return
+	}
+
+	if n.withVote {
+		if err := b.SendVote(to); err != nil {
+			log.Printf("cannot send vote to user %d: %s", n.userID, err)
+		}
+	}
+}
+
+// notifyGameTomorrow - remind players going to the game the evening before it, once per vote.
+func (b *Bot) notifyGameTomorrow(now time.Time) error {
+	if b.Pinned == nil {
+		return nil
+	}
+
+	game := b.GameDate()
+	remindAt := time.Date(game.Year(), game.Month(), game.Day()-1, 20, 0, 0, 0, game.Location())
+	if now.Before(remindAt) || !now.Before(game) {
+		return nil
+	}
+
+	rem, err := b.Store.GetReminderByVoteID(b.getMsgID(), NotifyGameTomorrow)
+	if err != nil || rem != nil {
+		return err
+	}
+
+	data, err := b.voteData()
+	if err != nil {
+		return err
+	}
+
+	// recorded first, a failed send is better than a reminder every tick.
+	if _, err := b.Store.CreateReminder(b.getMsgID(), NotifyGameTomorrow); err != nil {
+		return err
+	}
+
+	going := map[int]bool{}
+	for _, v := range data.Going.Voters {
+		going[v.ID] = true
+	}
+	b.notify(NotifyGameTomorrow, "notify.game_tomorrow", going, false)
+
+	return nil
+}
+
+// notifyPromoted - tell players who moved from the waitlist to the confirmed
+// list between `before` and `after`.
+func (b *Bot) notifyPromoted(before, after *VoteData) {
+	waiting := map[int]bool{}
+	for _, v := range before.Waitlist {
+		waiting[v.ID] = true
+	}
+
+	promoted := map[int]bool{}
+	for _, v := range after.Going.Voters {
+		if waiting[v.ID] {
+			promoted[v.ID] = true
+		}
+	}
+
+	if len(promoted) > 0 {
+		b.notify(NotifyPromoted, "notify.promoted", promoted, false)
+	}
+}
@@ -0,0 +1,144 @@
+package vote
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/pkg/errors"
+)
+
+// reminderNudge - kind of the reminder recorded when regulars are nudged.
+const reminderNudge = "nudge"
+
+// Regulars - players of the vote channel who agreed at least Regulars.MinGames times
+// in the last Regulars.Games games of the channel before the current one.
+func (b *Bot) Regulars() ([]Voter, error) {
+	cfg := b.Config().Regulars
+	games, minGames := cfg.Games, cfg.MinGames
+	if games == 0 || b.Channel == nil {
+		return nil, nil
+	}
+
+	current := 0
+	if b.Pinned != nil {
+		current = b.getMsgID()
+	}
+
+	// vote ids are message ids unique only within a chat, the history is taken by games of the channel.
+	history, err := b.chatGames(b.Channel.ID, time.Time{}, b.GameDate())
+	if err != nil {
+		return nil, err
+	}
+	var recent []model.Game
+	for _, g := range history {
+		if g.VoteID != current {
+			recent = append(recent, g)
+		}
+	}
+	if len(recent) > games {
+		recent = recent[len(recent)-games:]
+	}
+
+	yesBtn, _ := b.getButtons()
+	agreed := map[int]int{}
+	latest := map[int]model.Vote{}
+	for _, g := range recent {
+		votes, err := b.Store.GetVotesByVoteID(g.VoteID)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range votes {
+			if v.PressedBtn == yesBtn.Data {
+				agreed[v.UserID]++
+			}
+			latest[v.UserID] = v
+		}
+	}
+
+	rule := b.channelSettings().DisplayName
+	var regulars []Voter
+	for id, n := range agreed {
+		if n < minGames {
+			continue
+		}
+		v := latest[id]
+		regulars = append(regulars, Voter{
+			ID:       id,
+			Name:     displayName(v.Player, rule),
+			Username: v.Player.Username,
+		})
+	}
+	sort.Slice(regulars, func(i, j int) bool { return regulars[i].Name < regulars[j].Name })
+
+	return regulars, nil
+}
+
+// Nudge - remind regulars who haven't pressed any button of the current vote
+// NudgeHours before the kickoff, once per vote. Subscribers get a direct
+// message, the rest are mentioned in the group.
+func (b *Bot) Nudge(now time.Time) error {
+	hours := b.Config().NudgeHours
+	if hours == 0 || b.Pinned == nil {
+		return nil
+	}
+
+	game := b.GameDate()
+	if now.Before(game.Add(-time.Duration(hours)*time.Hour)) || !now.Before(game) {
+		return nil
+	}
+
+	rem, err := b.Store.GetReminderByVoteID(b.getMsgID(), reminderNudge)
+	if err != nil || rem != nil {
+		return err
+	}
+
+	regulars, err := b.Regulars()
+	if err != nil {
+		return err
+	}
+
+	votes, err := b.Store.GetVotesByVoteID(b.getMsgID())
+	if err != nil {
+		return err
+	}
+	voted := map[int]bool{}
+	for _, v := range votes {
+		voted[v.UserID] = true
+	}
+
+	if _, err := b.Store.CreateReminder(b.getMsgID(), reminderNudge); err != nil {
+		return err
+	}
+
+	missing := map[int]bool{}
+	for _, r := range regulars {
+		if !voted[r.ID] {
+			missing[r.ID] = true
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	messaged := b.notify(NotifyNudge, "notify.nudge", missing, true)
+
+	mk := b.markup()
+	var mentions []string
+	for _, r := range regulars {
+		if missing[r.ID] && !messaged[r.ID] {
+			mentions = append(mentions, mk.mention(r.Name, r.ID))
+		}
+	}
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	text := translate(b.channelLanguage(), "nudge.group", strings.Join(mentions, ", "))
+	if _, err := b.Send(b.Channel, text, mk.parseMode()); err != nil {
+		return errors.Wrap(err, "cannot nudge regulars")
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package vote
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+)
+
+func TestRegularsOfChannel(t *testing.T) {
+	b, _ := newTestBot(t, func(c *Config) {
+		c.Regulars.Games = 2
+		c.Regulars.MinGames = 2
+	})
+	b.Tick(time.Now())
+
+	past := time.Now().AddDate(0, 0, -7)
+	games := []struct {
+		chatID int64
+		voteID int
+		userID int
+	}{
+		// the oldest game of the channel is out of the last 2.
+		{testChannel.ID, 1, testBob.ID},
+		{0, 2, testMax.ID},
+		{testChannel.ID, 3, testMax.ID},
+		// games of another chat don't count.
+		{-200, 4, testBob.ID},
+		{-200, 5, testBob.ID},
+	}
+	for i, g := range games {
+		date := past.Add(time.Duration(i) * time.Hour)
+		if err := b.Store.SaveGame(&model.Game{ChatID: g.chatID, VoteID: g.voteID, Date: date}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.Store.CreateVote(&model.Vote{VoteID: g.voteID, UserID: g.userID, PressedBtn: b.VoteData(true)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := b.Store.CreateVote(&model.Vote{VoteID: 1, UserID: testMax.ID, PressedBtn: b.VoteData(true)}); err != nil {
+		t.Fatal(err)
+	}
+
+	regulars, err := b.Regulars()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regulars) != 1 || regulars[0].ID != testMax.ID {
+		t.Errorf("regulars %+v, want user %d", regulars, testMax.ID)
+	}
+}
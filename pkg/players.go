@@ -0,0 +1,111 @@
+package vote
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// RegisterPlayer - create player of user in chat or refresh its names from telegram.
+func (b *Bot) RegisterPlayer(chatID int64, user *tb.User) (*model.Player, error) {
+	p := &model.Player{
+		ChatID:    chatID,
+		UserID:    user.ID,
+		Username:  user.Username,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+	}
+
+	if err := b.Store.SavePlayer(p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// FindUser - find user by numeric id or @username among players of chat
+// and players backfilled from old votes without chat.
+func (b *Bot) FindUser(chatID int64, query string) (*tb.User, error) {
+	query = strings.TrimPrefix(strings.TrimSpace(query), "@")
+	if query == "" {
+		return nil, errors.New("user is not specified")
+	}
+
+	if id, err := strconv.Atoi(query); err == nil {
+		return &tb.User{ID: id}, nil
+	}
+
+	players, err := b.chatPlayers(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range players {
+		if strings.EqualFold(p.Username, query) {
+			return &tb.User{ID: p.UserID, Username: p.Username, FirstName: p.FirstName, LastName: p.LastName}, nil
+		}
+	}
+
+	return nil, errors.Errorf("user @%s is not found", query)
+}
+
+// chatPlayers - players of chat followed by the players backfilled from old votes
+// without chat who are not in the chat yet, they are adopted by the first chat they act in.
+func (b *Bot) chatPlayers(chatID int64) ([]model.Player, error) {
+	players, err := b.Store.GetPlayers(chatID)
+	if err != nil {
+		return nil, err
+	}
+	backfilled, err := b.Store.GetPlayers(0)
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[int]bool{}
+	for _, p := range players {
+		known[p.UserID] = true
+	}
+	for _, p := range backfilled {
+		if !known[p.UserID] {
+			players = append(players, p)
+		}
+	}
+
+	return players, nil
+}
+
+// maxNickname - longest nickname in runes.
+const maxNickname = 32
+
+// SetNickname - set nickname of user in chat, empty one resets it to the telegram name.
+func (b *Bot) SetNickname(chatID int64, userID int, nickname string) error {
+	nickname = strings.Join(strings.Fields(nickname), " ")
+	if utf8.RuneCountInString(nickname) > maxNickname {
+		return errors.Errorf("nickname is longer than %d characters", maxNickname)
+	}
+
+	return b.Store.SetNickname(chatID, userID, nickname)
+}
+
+// displayName - name of player shown by `rule`, the full telegram name
+// if the player has no value for the rule.
+func displayName(p model.Player, rule string) string {
+	name := p.Name()
+	switch {
+	case rule == DisplayNickname && p.Nickname != "":
+		name = p.Nickname
+	case rule == DisplayFirst && p.FirstName != "":
+		name = p.FirstName
+	case rule == DisplayUsername && p.Username != "":
+		name = "@" + p.Username
+	}
+	if name == "" {
+		name = strconv.Itoa(p.UserID)
+	}
+
+	return name
+}
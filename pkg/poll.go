@@ -0,0 +1,398 @@
+package vote
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// maxPollSlots - most candidate times in a poll, one button row each.
+const maxPollSlots = 8
+
+const winnerSymbol = "🏆"
+
+// pollLayouts - accepted layouts of poll slots, slots without year are
+// in the nearest year they are not in the past.
+var pollLayouts = []string{"2006-01-02 15:04", "02.01.2006 15:04", "02.01 15:04"}
+
+// pollDateLayout - layout of slots in the poll message.
+const pollDateLayout = "Mon 2 Jan 15:04"
+
+// ParseSlots - parse candidate game times separated by ";" or new lines
+// in location `loc`, they must be after `now`. Slots are returned in order.
+func ParseSlots(s string, loc *time.Location, now time.Time) ([]time.Time, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '\n' })
+
+	seen := map[time.Time]bool{}
+	var slots []time.Time
+	for _, f := range fields {
+		f = strings.Join(strings.Fields(f), " ")
+		if f == "" {
+			continue
+		}
+
+		t, err := parseSlot(f, loc, now)
+		if err != nil {
+			return nil, err
+		}
+		if !t.After(now) {
+			return nil, errors.Errorf("slot %q is in the past", f)
+		}
+		if !seen[t] {
+			seen[t] = true
+			slots = append(slots, t)
+		}
+	}
+
+	if len(slots) < 2 {
+		return nil, errors.New("poll needs at least 2 slots")
+	}
+	if len(slots) > maxPollSlots {
+		return nil, errors.Errorf("poll has more than %d slots", maxPollSlots)
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Before(slots[j]) })
+
+	return slots, nil
+}
+
+func parseSlot(s string, loc *time.Location, now time.Time) (time.Time, error) {
+	for _, layout := range pollLayouts {
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err != nil {
+			continue
+		}
+		if !strings.Contains(layout, "2006") {
+			now := now.In(loc)
+			t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+			if !t.After(now) {
+				t = t.AddDate(1, 0, 0)
+			}
+		}
+		return t, nil
+	}
+
+	return time.Time{}, errors.Errorf("wrong slot %q", s)
+}
+
+// CreatePoll - send availability poll over `slots` to the vote channel,
+// players mark every slot they can make.
+func (b *Bot) CreatePoll(slots []time.Time) (*model.Poll, error) {
+	if b.Channel == nil {
+		return nil, errors.New("no channel")
+	}
+
+	p := &model.Poll{ChatID: b.Channel.ID}
+	if err := b.Store.CreatePoll(p, slots); err != nil {
+		return nil, err
+	}
+
+	text, mrk, err := b.pollMessage(p)
+	if err != nil {
+		return nil, err
+	}
+	m, err := b.Send(b.Channel, text, mrk, b.markup().parseMode())
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot send poll")
+	}
+
+	p.MessageID = m.ID
+	if err := b.Store.SavePoll(p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// ClosePoll - stop the latest open poll of chat, the message shows results
+// and lets admins promote a slot into the vote.
+func (b *Bot) ClosePoll(chatID int64) (*model.Poll, error) {
+	p, err := b.Store.GetLastPoll(chatID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, errors.New("no open poll")
+	}
+
+	p.Closed = true
+	if err := b.Store.SavePoll(p); err != nil {
+		return nil, err
+	}
+
+	return p, b.updatePoll(p)
+}
+
+// PromoteSlot - replace the current vote with a vote for the game at the slot of closed poll.
+func (b *Bot) PromoteSlot(pollID, slotID uint) error {
+	b.voteMu.Lock()
+	defer b.voteMu.Unlock()
+
+	p, err := b.Store.GetPoll(pollID)
+	if err != nil {
+		return err
+	}
+	if !p.Closed {
+		return errors.Errorf("poll %d is open", pollID)
+	}
+	if p.Promoted != 0 {
+		return errors.Errorf("poll %d is already promoted", pollID)
+	}
+	if b.Channel == nil || b.Channel.ID != p.ChatID {
+		return errors.Errorf("poll %d is not in the vote channel", pollID)
+	}
+
+	var slot *model.PollSlot
+	for i := range p.Slots {
+		if p.Slots[i].ID == slotID {
+			slot = &p.Slots[i]
+		}
+	}
+	if slot == nil {
+		return errors.Errorf("poll %d has no slot %d", pollID, slotID)
+	}
+	if !slot.Date.After(time.Now()) {
+		return errors.Errorf("slot %d is in the past", slotID)
+	}
+
+	if err := b.replaceVote(slot.Date); err != nil {
+		return err
+	}
+
+	p.Promoted = slotID
+	if err := b.Store.SavePoll(p); err != nil {
+		return err
+	}
+
+	return b.updatePoll(p)
+}
+
+// specialGame - date of the current vote game if it is played
+// off the chat schedule.
+func (b *Bot) specialGame() (time.Time, bool) {
+	if b.Pinned == nil || b.Channel == nil {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return b.nextGame, !b.nextGame.IsZero()
+	}
+
+	voteID := b.getMsgID()
+	b.mu.Lock()
+	date, ok := b.gameDates[voteID]
+	b.mu.Unlock()
+	if ok {
+		return date, !date.IsZero()
+	}
+
+	g, err := b.Store.GetGame(b.Channel.ID, voteID)
+	if err != nil {
+		log.Printf("cannot get game: %s", err)
+		return time.Time{}, false
+	}
+	// the game is unknown until the vote is saved, don't remember it yet.
+	if g == nil {
+		return time.Time{}, false
+	}
+	if g.Special {
+		date = g.Date
+	}
+
+	b.mu.Lock()
+	b.gameDates[voteID] = date
+	b.mu.Unlock()
+
+	return date, !date.IsZero()
+}
+
+// pollWinner - slot most players can make, the earliest of equal ones.
+func pollWinner(p *model.Poll) uint {
+	var winner uint
+	most := 0
+	for _, s := range p.Slots {
+		if len(s.Answers) > most {
+			winner, most = s.ID, len(s.Answers)
+		}
+	}
+
+	return winner
+}
+
+// pollMessage - poll text with a button per slot: answers while the poll is open
+// and promotion after it is closed, promoted poll has no buttons.
+func (b *Bot) pollMessage(p *model.Poll) (string, *tb.ReplyMarkup, error) {
+	settings, err := b.Settings(p.ChatID)
+	if err != nil {
+		return "", nil, err
+	}
+	lang, loc := settings.Language, settings.Location()
+	mk := b.markup()
+
+	title := "poll.title"
+	if p.Closed {
+		title = "poll.results"
+	}
+	winner := pollWinner(p)
+
+	lines := []string{mk.escape(translate(lang, title))}
+	var keys [][]tb.InlineButton
+	for _, s := range p.Slots {
+		date := formatDate(s.Date.In(loc), pollDateLayout, lang)
+
+		var names []string
+		for _, a := range s.Answers {
+			player, err := b.Store.GetPlayer(p.ChatID, a.UserID)
+			if err != nil {
+				return "", nil, err
+			}
+			if player == nil {
+				player = &model.Player{UserID: a.UserID}
+			}
+			names = append(names, mk.mention(displayName(*player, settings.DisplayName), a.UserID))
+		}
+
+		mark := ""
+		if p.Promoted == s.ID || (p.Promoted == 0 && p.Closed && winner == s.ID) {
+			mark = winnerSymbol + " "
+		}
+		line := mark + mk.escape(fmt.Sprintf("%s — %d", date, len(s.Answers)))
+		if len(names) > 0 {
+			line += ": " + strings.Join(names, ", ")
+		}
+		lines = append(lines, line)
+
+		data := fmt.Sprintf("%d:%d", p.ID, s.ID)
+		switch {
+		case !p.Closed:
+			keys = append(keys, []tb.InlineButton{{Unique: "poll", Text: fmt.Sprintf("%s (%d)", date, len(s.Answers)), Data: data}})
+		case p.Promoted == 0:
+			keys = append(keys, []tb.InlineButton{{Unique: "poll_promote", Text: translate(lang, "btn.poll_promote", date), Data: data}})
+		}
+	}
+
+	return strings.Join(lines, "\n"), &tb.ReplyMarkup{InlineKeyboard: keys}, nil
+}
+
+// updatePoll - re-render message of poll.
+func (b *Bot) updatePoll(p *model.Poll) error {
+	text, mrk, err := b.pollMessage(p)
+	if err != nil {
+		return err
+	}
+
+	msg := tb.StoredMessage{MessageID: strconv.Itoa(p.MessageID), ChatID: p.ChatID}
+	if _, err := b.Edit(msg, text, mrk, b.markup().parseMode()); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		return errors.Wrap(err, "cannot edit poll message")
+	}
+
+	return nil
+}
+
+// hasSlot - report whether poll has slot `slotID`.
+func hasSlot(p *model.Poll, slotID uint) bool {
+	for _, s := range p.Slots {
+		if s.ID == slotID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pollButton - poll and slot ids from button data.
+func pollButton(data string) (uint, uint, error) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 2 {
+		return 0, 0, errors.Errorf("wrong poll button %q", data)
+	}
+	pollID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "wrong poll button %q", data)
+	}
+	slotID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "wrong poll button %q", data)
+	}
+
+	return uint(pollID), uint(slotID), nil
+}
+
+// pollHandler - mark or unmark the slot for player who pressed it.
+func (b *Bot) pollHandler(c *tb.Callback) {
+	lang := b.channelLanguage()
+
+	pollID, slotID, err := pollButton(c.Data)
+	if err != nil {
+		log.Printf("cannot answer poll: %s", err)
+		b.Respond(c, &tb.CallbackResponse{})
+		return
+	}
+	p, err := b.Store.GetPoll(pollID)
+	if err != nil {
+		log.Printf("cannot answer poll: %s", err)
+		b.Respond(c, &tb.CallbackResponse{})
+		return
+	}
+	if p.Closed {
+		b.Respond(c, &tb.CallbackResponse{Text: translate(lang, "poll.closed"), ShowAlert: true})
+		return
+	}
+	if !hasSlot(p, slotID) {
+		log.Printf("cannot answer poll: poll %d has no slot %d", pollID, slotID)
+		b.Respond(c, &tb.CallbackResponse{})
+		return
+	}
+
+	player, err := b.RegisterPlayer(p.ChatID, c.Sender)
+	if err != nil {
+		log.Printf("cannot save player: %s", err)
+		b.Respond(c, &tb.CallbackResponse{})
+		return
+	}
+	marked, err := b.Store.TogglePollAnswer(slotID, c.Sender.ID, player.ID)
+	if err != nil {
+		log.Printf("cannot answer poll: %s", err)
+		b.Respond(c, &tb.CallbackResponse{})
+		return
+	}
+
+	key := "poll.unmarked"
+	if marked {
+		key = "poll.marked"
+	}
+	b.Respond(c, &tb.CallbackResponse{Text: translate(lang, key)})
+
+	if p, err = b.Store.GetPoll(pollID); err == nil {
+		err = b.updatePoll(p)
+	}
+	if err != nil {
+		log.Printf("cannot update poll: %s", err)
+	}
+}
+
+// promoteHandler - promote the slot of closed poll into the vote, admins only.
+func (b *Bot) promoteHandler(c *tb.Callback) {
+	lang := b.channelLanguage()
+
+	if c.Message == nil || !b.HasRole(c.Message.Chat, c.Sender, RoleAdmin) {
+		b.Respond(c, &tb.CallbackResponse{Text: translate(lang, "permission_denied"), ShowAlert: true})
+		return
+	}
+
+	pollID, slotID, err := pollButton(c.Data)
+	if err == nil {
+		err = b.PromoteSlot(pollID, slotID)
+	}
+	if err != nil {
+		log.Printf("cannot promote poll slot: %s", err)
+		b.Respond(c, &tb.CallbackResponse{Text: translate(lang, "poll.cannot_promote"), ShowAlert: true})
+		return
+	}
+
+	b.Respond(c, &tb.CallbackResponse{Text: translate(lang, "poll.promoted")})
+}
@@ -0,0 +1,128 @@
+package vote
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func TestParseSlots(t *testing.T) {
+	now := time.Date(2024, 12, 30, 12, 0, 0, 0, time.UTC)
+
+	slots, err := ParseSlots("02.01 19:30;\n2024-12-31 18:00; 31.12.2024  18:00", time.UTC, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Time{
+		time.Date(2024, 12, 31, 18, 0, 0, 0, time.UTC),
+		time.Date(2025, 1, 2, 19, 30, 0, 0, time.UTC),
+	}
+	if len(slots) != len(want) || !slots[0].Equal(want[0]) || !slots[1].Equal(want[1]) {
+		t.Errorf("slots are %v, want %v", slots, want)
+	}
+
+	for _, s := range []string{"2024-12-31 18:00", "2024-12-29 18:00; 2024-12-31 18:00", "tomorrow; 2024-12-31 18:00"} {
+		if _, err := ParseSlots(s, time.UTC, now); err == nil {
+			t.Errorf("slots %q are accepted", s)
+		}
+	}
+}
+
+func TestPollPromotion(t *testing.T) {
+	b, tg := newTestBot(t, nil)
+	b.Tick(time.Now())
+	scheduled := pinned(t, tg)
+
+	// the first slot is in the next week.
+	now := time.Now().UTC()
+	slots := []time.Time{
+		time.Date(now.Year(), now.Month(), now.Day()+8, 20, 0, 0, 0, time.UTC),
+		time.Date(now.Year(), now.Month(), now.Day()+9, 20, 0, 0, 0, time.UTC),
+	}
+	other, err := b.CreatePoll(slots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := b.CreatePoll(slots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poll := &tb.Message{ID: p.MessageID, Chat: testChannel}
+
+	if err := tg.Press(poll, "poll", testMax); err != nil {
+		t.Fatal(err)
+	}
+	if text := tg.Message(poll).Text; !strings.Contains(text, "Max") {
+		t.Errorf("answer is not shown: %q", text)
+	}
+
+	// forged slot of another poll is not answered.
+	if err := tg.PressData(poll, "poll", fmt.Sprintf("%d:%d", p.ID, other.Slots[0].ID), testBob); err != nil {
+		t.Fatal(err)
+	}
+	if got := answers(t, b, other.ID); got != 0 {
+		t.Errorf("other poll has %d answers, want 0", got)
+	}
+
+	if _, err := b.ClosePoll(testChannel.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := tg.Press(poll, "poll", testBob); err != nil && !strings.Contains(err.Error(), "no button") {
+		t.Fatal(err)
+	}
+	if got := answers(t, b, p.ID); got != 1 {
+		t.Errorf("closed poll has %d answers, want 1", got)
+	}
+
+	// players cannot promote slots.
+	if err := tg.Press(poll, "poll_promote", testMax); err != nil {
+		t.Fatal(err)
+	}
+	if got := pinned(t, tg); got.ID != scheduled.ID {
+		t.Fatal("slot is promoted by player")
+	}
+
+	if err := tg.Press(poll, "poll_promote", testAdmin); err != nil {
+		t.Fatal(err)
+	}
+	special := pinned(t, tg)
+	if special.ID == scheduled.ID {
+		t.Fatal("slot is not promoted")
+	}
+	if game := b.GameDate(); !game.Equal(slots[0]) {
+		t.Errorf("game is at %s, want %s", game, slots[0])
+	}
+	p, err = b.Store.GetPoll(p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Promoted != p.Slots[0].ID {
+		t.Errorf("poll promoted slot %d, want %d", p.Promoted, p.Slots[0].ID)
+	}
+
+	// the special game stays pinned until it is played.
+	b.Tick(slots[0].Add(-4 * time.Hour))
+	if got := pinned(t, tg); got.ID != special.ID {
+		t.Error("special game is unpinned before it is played")
+	}
+}
+
+// answers - number of answers of poll.
+func answers(t *testing.T, b *Bot, pollID uint) int {
+	t.Helper()
+
+	p, err := b.Store.GetPoll(pollID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	for _, s := range p.Slots {
+		n += len(s.Answers)
+	}
+
+	return n
+}
@@ -0,0 +1,250 @@
+package vote
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// proxySymbol - marks votes cast by admin on behalf of the player.
+const proxySymbol = "✍️"
+
+var voteOptions = map[string]bool{
+	"yes": true, "1": true, "+": true, "да": true,
+	"no": false, "0": false, "-": false, "нет": false,
+}
+
+// AuditEntry - change of the current vote with names of who made it and whose vote it is.
+type AuditEntry struct {
+	At      time.Time
+	Actor   string
+	Player  string
+	Proxied bool
+	Removed bool
+	Agree   bool
+}
+
+// CastVote - store vote of player for button with `data` in the current vote
+// made by user `actorID`, which is the player or admin on their behalf,
+// and update the pinned message.
+func (b *Bot) CastVote(player *model.Player, data string, actorID int) error {
+	if b.Pinned == nil {
+		return errors.New("no vote")
+	}
+
+	before, err := b.voteData()
+	if err != nil {
+		return err
+	}
+
+	proxyBy := 0
+	if actorID != player.UserID {
+		proxyBy = actorID
+	}
+
+	// refusing again keeps the reason of the refusal.
+	reason := ""
+	if yesBtn, _ := b.getButtons(); data != yesBtn.Data {
+		refused, err := b.refusal(player.UserID)
+		if err != nil {
+			return err
+		}
+		if refused != nil {
+			reason = refused.Reason
+		}
+	}
+
+	_, err = b.Store.CreateVote(&model.Vote{
+		VoteID:     b.getMsgID(),
+		UserID:     player.UserID,
+		PlayerID:   player.ID,
+		PressedBtn: data,
+		ProxyBy:    proxyBy,
+		Reason:     reason,
+	})
+	if err != nil {
+		return err
+	}
+	b.audit(player, model.AuditVote, data, actorID)
+	if yesBtn, _ := b.getButtons(); data != yesBtn.Data {
+		b.lateCancel(before, player, actorID, time.Now())
+		b.releaseDuties(player.UserID)
+	}
+
+	// votes of pinned messages restored on start have no game yet.
+	if err := b.saveGame(); err != nil {
+		log.Printf("cannot save game: %s", err)
+	}
+
+	if after, err := b.voteData(); err == nil {
+		b.notifyPromoted(before, after)
+	}
+
+	return b.UpdateVote()
+}
+
+// RemoveVote - remove vote of player from the current vote on behalf of user `actorID`
+// and update the pinned message.
+func (b *Bot) RemoveVote(player *model.Player, actorID int) error {
+	if b.Pinned == nil {
+		return errors.New("no vote")
+	}
+
+	before, err := b.voteData()
+	if err != nil {
+		return err
+	}
+
+	if err := b.Store.DeleteVote(b.getMsgID(), player.UserID); err != nil {
+		return err
+	}
+	b.audit(player, model.AuditRemove, "", actorID)
+	b.lateCancel(before, player, actorID, time.Now())
+	b.releaseDuties(player.UserID)
+
+	if after, err := b.voteData(); err == nil {
+		b.notifyPromoted(before, after)
+	}
+
+	return b.UpdateVote()
+}
+
+// ProxyPlayer - player of telegram user in chat, registered if it is not known yet.
+func (b *Bot) ProxyPlayer(chatID int64, user *tb.User) (*model.Player, error) {
+	p, err := b.Store.GetPlayer(chatID, user.ID)
+	if err != nil || p != nil {
+		return p, err
+	}
+
+	return b.RegisterPlayer(chatID, user)
+}
+
+// FindGuest - player of chat without telegram account called `name`, nil if there is none.
+func (b *Bot) FindGuest(chatID int64, name string) (*model.Player, error) {
+	name = strings.Join(strings.Fields(name), " ")
+
+	players, err := b.Store.GetPlayers(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, p := range players {
+		if p.UserID < 0 && strings.EqualFold(p.FirstName, name) {
+			return &players[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Guest - player of chat without telegram account called `name`, created if
+// there is none. Guests get negative user ids.
+func (b *Bot) Guest(chatID int64, name string) (*model.Player, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return nil, errors.New("guest name is required")
+	}
+
+	p, err := b.FindGuest(chatID, name)
+	if err != nil || p != nil {
+		return p, err
+	}
+
+	players, err := b.Store.GetPlayers(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	lastID := 0
+	for _, p := range players {
+		if p.UserID < lastID {
+			lastID = p.UserID
+		}
+	}
+
+	p = &model.Player{ChatID: chatID, UserID: lastID - 1, FirstName: name}
+	if err := b.Store.SavePlayer(p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// ParseVoteOption - report whether option `s` like yes, no, + or да agrees,
+// `ok` is false for unknown options.
+func ParseVoteOption(s string) (agree, ok bool) {
+	agree, ok = voteOptions[strings.ToLower(strings.TrimSpace(s))]
+	return agree, ok
+}
+
+// VoteAudit - changes of the current vote in order they were made.
+func (b *Bot) VoteAudit() ([]AuditEntry, error) {
+	if b.Pinned == nil || b.Channel == nil {
+		return nil, errors.New("no vote")
+	}
+
+	audits, err := b.Store.GetVoteAudits(b.getMsgID())
+	if err != nil {
+		return nil, err
+	}
+	players, err := b.Store.GetPlayers(b.Channel.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := b.channelSettings()
+	names := map[int]string{}
+	for _, p := range players {
+		names[p.UserID] = displayName(p, settings.DisplayName)
+	}
+	name := func(userID int) string {
+		if n, ok := names[userID]; ok {
+			return n
+		}
+		return strconv.Itoa(userID)
+	}
+
+	yesBtn, _ := b.getButtons()
+	entries := make([]AuditEntry, 0, len(audits))
+	for _, a := range audits {
+		entries = append(entries, AuditEntry{
+			At:      a.CreatedAt.In(settings.Location()),
+			Actor:   name(a.ActorID),
+			Player:  name(a.UserID),
+			Proxied: a.Proxied(),
+			Removed: a.Action == model.AuditRemove,
+			Agree:   a.PressedBtn == yesBtn.Data,
+		})
+	}
+
+	return entries, nil
+}
+
+// VoteData - button data of agree or disagree vote.
+func (b *Bot) VoteData(agree bool) string {
+	yesBtn, noBtn := b.getButtons()
+	if agree {
+		return yesBtn.Data
+	}
+
+	return noBtn.Data
+}
+
+func (b *Bot) audit(player *model.Player, action, data string, actorID int) {
+	err := b.Store.AddVoteAudit(&model.VoteAudit{
+		VoteID:     b.getMsgID(),
+		UserID:     player.UserID,
+		PlayerID:   player.ID,
+		ActorID:    actorID,
+		Action:     action,
+		PressedBtn: data,
+	})
+	if err != nil {
+		log.Printf("cannot audit vote: %s", err)
+	}
+}
@@ -0,0 +1,94 @@
+package vote
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxyVote(t *testing.T) {
+	b, tg := newTestBot(t, nil)
+	b.Tick(time.Now())
+
+	player, err := b.ProxyPlayer(testChannel.ID, testMax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.CastVote(player, b.VoteData(true), testAdmin.ID); err != nil {
+		t.Fatal(err)
+	}
+	if agree, _ := caption(t, tg); !strings.Contains(agree, "Max") || !strings.Contains(agree, proxySymbol) {
+		t.Errorf("proxy vote is not marked: %q", agree)
+	}
+
+	guest, err := b.Guest(testChannel.ID, " Uncle  Joe ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if guest.UserID >= 0 {
+		t.Errorf("guest has user id %d", guest.UserID)
+	}
+	if err := b.CastVote(guest, b.VoteData(false), testAdmin.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, disagree := caption(t, tg); !strings.Contains(disagree, "Uncle Joe") {
+		t.Errorf("guest is not refused: %q", disagree)
+	}
+
+	// the player's own press replaces the proxy vote.
+	press(t, b, tg, "yes", testMax)
+	if agree, _ := caption(t, tg); !strings.Contains(agree, "Max") || strings.Contains(agree, proxySymbol) {
+		t.Errorf("own vote is marked as proxy: %q", agree)
+	}
+
+	if err := b.RemoveVote(guest, testAdmin.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, disagree := caption(t, tg); strings.Contains(disagree, "Uncle Joe") {
+		t.Errorf("guest vote is not removed: %q", disagree)
+	}
+
+	audit, err := b.VoteAudit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var proxied, removed int
+	for _, e := range audit {
+		if e.Proxied {
+			proxied++
+		}
+		if e.Removed {
+			removed++
+		}
+	}
+	if len(audit) != 4 || proxied != 3 || removed != 1 {
+		t.Errorf("unexpected audit: %+v", audit)
+	}
+}
+
+func TestProxyVoteKeepsPlace(t *testing.T) {
+	b, tg := newTestBot(t, func(c *Config) { c.Limit = 1 })
+	b.Tick(time.Now())
+
+	player, err := b.ProxyPlayer(testChannel.ID, testMax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.CastVote(player, b.VoteData(true), testAdmin.ID); err != nil {
+		t.Fatal(err)
+	}
+	press(t, b, tg, "yes", testBob)
+
+	// confirming the vote made by admin keeps the place in the queue.
+	press(t, b, tg, "yes", testMax)
+	d, err := b.voteData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Going.Voters) != 1 || d.Going.Voters[0].ID != testMax.ID || d.Going.Voters[0].Proxied {
+		t.Errorf("going %+v, want own vote of user %d", d.Going.Voters, testMax.ID)
+	}
+	if len(d.Waitlist) != 1 || d.Waitlist[0].ID != testBob.ID {
+		t.Errorf("waitlist %+v, want user %d", d.Waitlist, testBob.ID)
+	}
+}
@@ -0,0 +1,127 @@
+package vote
+
+import (
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Preset reasons of refusal offered after pressing "no", other reasons are free text.
+const (
+	ReasonInjury = "injury"
+	ReasonTravel = "travel"
+	ReasonWork   = "work"
+)
+
+// ReasonPresets - preset reasons in display order.
+var ReasonPresets = []string{ReasonInjury, ReasonTravel, ReasonWork}
+
+// maxReason - longest reason in runes.
+const maxReason = 64
+
+// askReason - ask player who refused why privately, the answer is a preset
+// button or the next private message. Players who never started the bot
+// can use /reason in the group.
+func (b *Bot) askReason(user *tb.User) {
+	chatID := int64(user.ID)
+
+	var row []tb.InlineButton
+	for _, r := range ReasonPresets {
+		row = append(row, tb.InlineButton{Unique: "reason", Text: b.T(chatID, "reason."+r), Data: r})
+	}
+
+	if _, err := b.Send(user, b.T(chatID, "reason.ask"), &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{row}}); err != nil {
+		log.Printf("cannot ask reason of user %d: %s", user.ID, err)
+		return
+	}
+
+	b.mu.Lock()
+	b.awaitingReason[user.ID] = b.getMsgID()
+	b.mu.Unlock()
+}
+
+// AwaitsReason - report whether the bot waits for user to explain refusal in the current vote.
+func (b *Bot) AwaitsReason(userID int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	voteID, ok := b.awaitingReason[userID]
+	return ok && b.Pinned != nil && voteID == b.getMsgID()
+}
+
+// SetReason - set reason of user refusal in the current vote, a preset key
+// or free text, and update the pinned message. Empty reason removes it.
+func (b *Bot) SetReason(userID int, reason string) error {
+	if b.Pinned == nil {
+		return errors.New("no vote")
+	}
+
+	reason = strings.Join(strings.Fields(reason), " ")
+	if utf8.RuneCountInString(reason) > maxReason {
+		return errors.Errorf("reason is longer than %d characters", maxReason)
+	}
+
+	refused, err := b.refusal(userID)
+	if err != nil {
+		return err
+	}
+	if refused == nil {
+		return errors.Errorf("user %d has not refused", userID)
+	}
+
+	if err := b.Store.SetVoteReason(b.getMsgID(), userID, reason); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	delete(b.awaitingReason, userID)
+	b.mu.Unlock()
+
+	return b.UpdateVote()
+}
+
+// refusal - vote of user in the current vote if it is a refusal, nil otherwise.
+func (b *Bot) refusal(userID int) (*model.Vote, error) {
+	votes, err := b.Store.GetVotesByVoteID(b.getMsgID())
+	if err != nil {
+		return nil, err
+	}
+
+	yesBtn, _ := b.getButtons()
+	for i, v := range votes {
+		if v.UserID == userID && v.PressedBtn != yesBtn.Data {
+			return &votes[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// reasonText - reason as shown to people speaking `lang`, presets are translated.
+func reasonText(reason, lang string) string {
+	for _, r := range ReasonPresets {
+		if r == reason {
+			return translate(lang, "reason."+r)
+		}
+	}
+
+	return reason
+}
+
+func (b *Bot) reasonButtonHandler(c *tb.Callback) {
+	chatID := int64(c.Sender.ID)
+	if err := b.SetReason(c.Sender.ID, c.Data); err != nil {
+		log.Printf("cannot set reason: %s", err)
+		b.Respond(c, &tb.CallbackResponse{Text: b.T(chatID, "no_vote")})
+		return
+	}
+	b.Respond(c, &tb.CallbackResponse{})
+
+	if _, err := b.Edit(c.Message, b.T(chatID, "reason.saved", b.T(chatID, "reason."+c.Data))); err != nil {
+		log.Printf("cannot edit reason prompt: %s", err)
+	}
+}
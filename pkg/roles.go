@@ -0,0 +1,150 @@
+package vote
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Roles of users, every role has permissions of the lower ones.
+const (
+	RolePlayer    = "player"
+	RoleTreasurer = "treasurer"
+	RoleAdmin     = "admin"
+	RoleOwner     = "owner"
+)
+
+// GrantableRoles - roles that can be granted with /admin_add.
+var GrantableRoles = []string{RoleAdmin, RoleTreasurer, RolePlayer}
+
+var roleRanks = map[string]int{RolePlayer: 0, RoleTreasurer: 1, RoleAdmin: 2, RoleOwner: 3}
+
+// chatAdminsTTL - how long telegram chat admins are cached.
+const chatAdminsTTL = 10 * time.Minute
+
+type chatAdmins struct {
+	ids map[int]bool
+	at  time.Time
+}
+
+// IsOwner - report whether user is an owner of the bot from config.
+func (b *Bot) IsOwner(user *tb.User) bool {
+	for _, id := range b.Config().Owners {
+		if id == user.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RoleOf - return the highest role of user in chat: owner from config,
+// role granted with /admin_add, admin from config or from telegram chat admins.
+func (b *Bot) RoleOf(chat *tb.Chat, user *tb.User) string {
+	if b.IsOwner(user) {
+		return RoleOwner
+	}
+
+	role := RolePlayer
+	raise := func(r string) {
+		if roleRanks[r] > roleRanks[role] {
+			role = r
+		}
+	}
+
+	for _, id := range b.Config().Admins {
+		if id == user.ID {
+			raise(RoleAdmin)
+		}
+	}
+
+	if chat == nil {
+		return role
+	}
+
+	stored, err := b.Store.GetRole(chat.ID, user.ID)
+	if err != nil {
+		log.Printf("cannot get role: %s", err)
+	}
+	if stored != nil {
+		raise(stored.Role)
+	}
+
+	if b.Config().InheritChatAdmins && b.isChatAdmin(chat, user) {
+		raise(RoleAdmin)
+	}
+
+	return role
+}
+
+// RoleRank - return rank of role, higher roles have bigger ranks.
+func RoleRank(role string) int {
+	return roleRanks[role]
+}
+
+// HasRole - report whether user has at least `role` in chat.
+func (b *Bot) HasRole(chat *tb.Chat, user *tb.User, role string) bool {
+	return roleRanks[b.RoleOf(chat, user)] >= roleRanks[role]
+}
+
+// GrantRole - store role of user in chat.
+func (b *Bot) GrantRole(chat *tb.Chat, user *tb.User, role string) error {
+	if _, ok := roleRanks[role]; !ok || role == RoleOwner {
+		return errors.Errorf("unknown role %q", role)
+	}
+
+	return b.Store.SetRole(model.Role{ChatID: chat.ID, UserID: user.ID, Name: userName(user), Role: role})
+}
+
+// RevokeRole - remove role granted to user in chat.
+func (b *Bot) RevokeRole(chat *tb.Chat, user *tb.User) error {
+	return b.Store.DeleteRole(chat.ID, user.ID)
+}
+
+func (b *Bot) isChatAdmin(chat *tb.Chat, user *tb.User) bool {
+	if chat.Type == tb.ChatPrivate {
+		return false
+	}
+
+	b.mu.Lock()
+	cached, ok := b.chatAdmins[chat.ID]
+	b.mu.Unlock()
+
+	if !ok || time.Since(cached.at) > chatAdminsTTL {
+		members, err := b.AdminsOf(chat)
+		if err != nil {
+			log.Printf("cannot get admins of chat %d: %s", chat.ID, err)
+			return cached.ids[user.ID]
+		}
+
+		cached = chatAdmins{ids: map[int]bool{}, at: time.Now()}
+		for _, m := range members {
+			if m.User != nil && (m.Role == tb.Creator || m.Role == tb.Administrator) {
+				cached.ids[m.User.ID] = true
+			}
+		}
+
+		b.mu.Lock()
+		b.chatAdmins[chat.ID] = cached
+		b.mu.Unlock()
+	}
+
+	return cached.ids[user.ID]
+}
+
+func userName(user *tb.User) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if name == "" && user.Username != "" {
+		name = "@" + user.Username
+	}
+	if name == "" {
+		name = strconv.Itoa(user.ID)
+	}
+
+	return name
+}
@@ -0,0 +1,73 @@
+package vote
+
+import (
+	"log"
+	"time"
+)
+
+// Tick - run one iteration of the weekly vote lifecycle at `now`:
+// unpin last week vote, refresh the current one or create a new one.
+func (b *Bot) Tick(now time.Time) {
+	b.voteMu.Lock()
+	defer b.voteMu.Unlock()
+
+	loc := b.channelSettings().Location()
+	now = now.In(loc)
+
+	un, date := b.pinnedInfo()
+	date = date.In(loc)
+
+	curYear, curWeek := now.ISOWeek()
+	pinYear, pinWeek := date.ISOWeek()
+	current := curWeek == pinWeek && curYear == pinYear
+	// special games stay pinned until they are played.
+	if game, ok := b.specialGame(); ok && now.Before(game) {
+		current = true
+	}
+
+	if !current && now.Hour() == 16 && un == b.Me.Username {
+		log.Println("Unpin message")
+
+		err := b.UnpinMessage()
+		if err != nil {
+			log.Printf("cannot upin, err: %v", err)
+		}
+	}
+
+	if b.Pinned != nil && un == b.Me.Username && current {
+		log.Println("Update vote")
+		if err := b.UpdateVote(); err != nil {
+			log.Printf("caught err: %s", err)
+		}
+	}
+
+	if b.Pinned == nil {
+		log.Println("Create vote")
+		if err := b.createVote(time.Time{}); err != nil {
+			log.Printf("caught error: %s", err)
+		}
+	}
+
+	game := b.GameDate()
+	remindAt := time.Date(game.Year(), game.Month(), game.Day()-1, 20, 0, 0, 0, loc)
+	if !now.Before(remindAt) && now.Before(remindAt.Add(time.Minute)) {
+		log.Println("send reminder")
+		// b.SendReminder()
+	}
+
+	if err := b.notifyGameTomorrow(now); err != nil {
+		log.Printf("cannot notify about the game: %s", err)
+	}
+	if err := b.Nudge(now); err != nil {
+		log.Printf("cannot nudge regulars: %s", err)
+	}
+	if err := b.notifyDrivers(now); err != nil {
+		log.Printf("cannot notify drivers: %s", err)
+	}
+	if err := b.assignDuties(now); err != nil {
+		log.Printf("cannot assign duties: %s", err)
+	}
+	b.FlushNotifications(now)
+
+	b.CreateHandlers()
+}
@@ -0,0 +1,275 @@
+package vote
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Sources of effective settings.
+const (
+	SourceDefault = "default"
+	SourceConfig  = "config"
+	SourceChat    = "chat"
+)
+
+// Keys of settings stored per chat.
+const (
+	languageSetting = "language"
+	weekdaySetting  = "weekday"
+	hourSetting     = "hour"
+	minuteSetting   = "minute"
+	timezoneSetting = "timezone"
+	displaySetting  = "display_name"
+)
+
+// SettingKeys - keys of all settings in display order.
+var SettingKeys = []string{languageSetting, timezoneSetting, weekdaySetting, hourSetting, minuteSetting, displaySetting}
+
+// Rules of player names display, every rule falls back to the full telegram name.
+const (
+	DisplayNickname = "nickname"
+	DisplayFirst    = "first"
+	DisplayUsername = "username"
+)
+
+// DisplayRules - all rules of player names display.
+var DisplayRules = []string{DisplayNickname, DisplayFirst, DisplayUsername}
+
+// DefaultTimezone - timezone of chats when neither config nor chat sets it.
+const DefaultTimezone = "Europe/Kiev"
+
+// Settings - effective settings of a chat, values from config file
+// layered with the ones changed at runtime and stored per chat.
+type Settings struct {
+	Language string
+	Weekday  int
+	Hour     int
+	Minute   int
+	Timezone string
+	// DisplayName - rule of player names display.
+	DisplayName string
+
+	// Sources - origin of every setting by key.
+	Sources map[string]string
+
+	// loc - location of Timezone loaded with the settings.
+	loc *time.Location
+}
+
+// timezones - locations by name, LoadLocation reads the zone file on every call.
+var timezones = struct {
+	sync.Mutex
+	m map[string]*time.Location
+}{m: map[string]*time.Location{}}
+
+// Settings - return effective settings of chat.
+func (b *Bot) Settings(chatID int64) (*Settings, error) {
+	cfg := b.Config()
+	s := &Settings{
+		Language:    DefaultLanguage,
+		Weekday:     cfg.Weekday,
+		Hour:        cfg.Hour,
+		Minute:      cfg.Minute,
+		Timezone:    DefaultTimezone,
+		DisplayName: DisplayNickname,
+		Sources: map[string]string{
+			languageSetting: SourceDefault,
+			timezoneSetting: SourceDefault,
+			weekdaySetting:  SourceConfig,
+			hourSetting:     SourceConfig,
+			minuteSetting:   SourceConfig,
+			displaySetting:  SourceDefault,
+		},
+	}
+
+	if cfg.Timezone != "" {
+		s.Timezone = cfg.Timezone
+		s.Sources[timezoneSetting] = SourceConfig
+	}
+	if cfg.DisplayName != "" {
+		s.DisplayName = cfg.DisplayName
+		s.Sources[displaySetting] = SourceConfig
+	}
+
+	// a broken timezone of config is refused on start, UTC is used then.
+	s.loc, _ = loadTimezone(s.Timezone)
+
+	stored, err := b.Store.GetSettings(chatID)
+	if err != nil {
+		return s, err
+	}
+
+	for _, st := range stored {
+		if err := s.set(st.Key, st.Value); err != nil {
+			log.Printf("ignore setting of chat %d: %s", chatID, err)
+			continue
+		}
+		s.Sources[st.Key] = SourceChat
+	}
+
+	return s, nil
+}
+
+func (s *Settings) set(key, value string) error {
+	switch key {
+	case languageSetting:
+		if _, ok := catalogs[value]; !ok {
+			return fmt.Errorf("unknown language %q", value)
+		}
+		s.Language = value
+	case weekdaySetting:
+		return setInt(&s.Weekday, key, value, 0, 6)
+	case hourSetting:
+		return setInt(&s.Hour, key, value, 0, 23)
+	case minuteSetting:
+		return setInt(&s.Minute, key, value, 0, 59)
+	case timezoneSetting:
+		loc, err := loadTimezone(value)
+		if err != nil {
+			return err
+		}
+		s.Timezone, s.loc = value, loc
+	case displaySetting:
+		if !Contains(DisplayRules, value) {
+			return fmt.Errorf("unknown display rule %q", value)
+		}
+		s.DisplayName = value
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+
+	return nil
+}
+
+// Value - return printable value of setting.
+func (s *Settings) Value(key string) string {
+	switch key {
+	case languageSetting:
+		return s.Language
+	case weekdaySetting:
+		return time.Weekday(s.Weekday).String()
+	case hourSetting:
+		return strconv.Itoa(s.Hour)
+	case minuteSetting:
+		return fmt.Sprintf("%02d", s.Minute)
+	case timezoneSetting:
+		return s.Timezone
+	case displaySetting:
+		return s.DisplayName
+	}
+
+	return ""
+}
+
+// loadTimezone - load IANA timezone, empty name and "Local" are refused
+// because they silently mean UTC and the server timezone.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+
+	timezones.Lock()
+	defer timezones.Unlock()
+
+	if loc, ok := timezones.m[name]; ok {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	timezones.m[name] = loc
+
+	return loc, nil
+}
+
+// Location - return location of chat timezone, UTC if it cannot be loaded.
+func (s *Settings) Location() *time.Location {
+	if s.loc != nil {
+		return s.loc
+	}
+
+	loc, err := loadTimezone(s.Timezone)
+	if err != nil {
+		log.Printf("cannot load timezone: %s", err)
+		return time.UTC
+	}
+
+	return loc
+}
+
+// SetTimezone - validate and store timezone of chat.
+func (b *Bot) SetTimezone(chatID int64, name string) error {
+	var check Settings
+	if err := check.set(timezoneSetting, name); err != nil {
+		return err
+	}
+
+	return b.Store.SetSetting(chatID, timezoneSetting, name)
+}
+
+// SetDisplayName - validate and store rule of player names display in chat.
+func (b *Bot) SetDisplayName(chatID int64, rule string) error {
+	var check Settings
+	if err := check.set(displaySetting, rule); err != nil {
+		return err
+	}
+
+	return b.Store.SetSetting(chatID, displaySetting, rule)
+}
+
+func setInt(dst *int, key, value string, min, max int) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return fmt.Errorf("%s: %q is out of range [%d, %d]", key, value, min, max)
+	}
+	*dst = n
+
+	return nil
+}
+
+// SetSchedule - validate and store game time of chat.
+func (b *Bot) SetSchedule(chatID int64, weekday, hour, minute int) error {
+	values := []struct {
+		key   string
+		value string
+	}{
+		{weekdaySetting, strconv.Itoa(weekday)},
+		{hourSetting, strconv.Itoa(hour)},
+		{minuteSetting, strconv.Itoa(minute)},
+	}
+
+	var check Settings
+	for _, v := range values {
+		if err := check.set(v.key, v.value); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range values {
+		if err := b.Store.SetSetting(chatID, v.key, v.value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// channelSettings - return effective settings of vote channel,
+// config values only if there is no channel yet.
+func (b *Bot) channelSettings() *Settings {
+	var chatID int64
+	if b.Channel != nil {
+		chatID = b.Channel.ID
+	}
+
+	s, err := b.Settings(chatID)
+	if err != nil {
+		log.Printf("cannot get settings of chat %d: %s", chatID, err)
+	}
+
+	return s
+}
@@ -0,0 +1,148 @@
+package vote
+
+import (
+	"sort"
+	"time"
+
+	"github.com/k33nice/vote-bot/pkg/model"
+)
+
+// Stats - attendance of chat players over games.
+type Stats struct {
+	// Games - number of games.
+	Games int
+	// Players - players who voted at least once, most refusals first.
+	Players []PlayerStats
+	// Reasons - all refusal reasons, most frequent first.
+	Reasons []ReasonCount
+	// LateCancels - number of late cancellations.
+	LateCancels int
+}
+
+// PlayerStats - votes of player over games.
+type PlayerStats struct {
+	ID      int
+	Name    string
+	Agreed  int
+	Refused int
+	// LateCancels and Fees - late cancellations of the player and the fees charged for them.
+	LateCancels int
+	Fees        int
+	// Reasons - reasons of player refusals, most frequent first.
+	Reasons []ReasonCount
+}
+
+// ReasonCount - how many times the reason was given, presets are translated.
+type ReasonCount struct {
+	Reason string
+	Count  int
+}
+
+// Stats - attendance and late cancellations of chat players over games
+// played in [from, to), reasons are in language `lang`.
+func (b *Bot) Stats(chatID int64, from, to time.Time, lang string) (*Stats, error) {
+	games, err := b.Games(chatID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{Games: len(games)}
+	players := map[int]*PlayerStats{}
+	reasons := map[int]map[string]int{}
+	total := map[string]int{}
+	for _, g := range games {
+		for _, v := range g.Votes {
+			p, ok := players[v.UserID]
+			if !ok {
+				p = &PlayerStats{ID: v.UserID}
+				players[v.UserID] = p
+				reasons[v.UserID] = map[string]int{}
+			}
+			// the latest name wins, games are in order.
+			p.Name = v.Name
+
+			if v.Option == "yes" {
+				p.Agreed++
+				continue
+			}
+			p.Refused++
+			if v.Reason != "" {
+				r := reasonText(v.Reason, lang)
+				reasons[v.UserID][r]++
+				total[r]++
+			}
+		}
+	}
+
+	cancellations, err := b.Store.GetLateCancellations(chatID)
+	if err != nil {
+		return nil, err
+	}
+	played := map[int]bool{}
+	for _, g := range games {
+		played[g.VoteID] = true
+	}
+	for _, c := range cancellations {
+		if !played[c.VoteID] {
+			continue
+		}
+		p, ok := players[c.UserID]
+		if !ok {
+			// votes removed by admin leave only the cancellation.
+			if p, err = b.statsPlayer(chatID, c.UserID); err != nil {
+				return nil, err
+			}
+			players[c.UserID] = p
+		}
+		p.LateCancels++
+		p.Fees += c.Fee
+		stats.LateCancels++
+	}
+
+	for id, p := range players {
+		p.Reasons = reasonCounts(reasons[id])
+		stats.Players = append(stats.Players, *p)
+	}
+	sort.Slice(stats.Players, func(i, j int) bool {
+		pi, pj := stats.Players[i], stats.Players[j]
+		if pi.Refused != pj.Refused {
+			return pi.Refused > pj.Refused
+		}
+		return pi.Name < pj.Name
+	})
+	stats.Reasons = reasonCounts(total)
+
+	return stats, nil
+}
+
+// statsPlayer - empty stats of user in chat named by the display rule of the chat.
+func (b *Bot) statsPlayer(chatID int64, userID int) (*PlayerStats, error) {
+	settings, err := b.Settings(chatID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := b.Store.GetPlayer(chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		p = &model.Player{UserID: userID}
+	}
+
+	return &PlayerStats{ID: userID, Name: displayName(*p, settings.DisplayName)}, nil
+}
+
+func reasonCounts(counts map[string]int) []ReasonCount {
+	list := make([]ReasonCount, 0, len(counts))
+	for r, n := range counts {
+		list = append(list, ReasonCount{Reason: r, Count: n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Reason < list[j].Reason
+	})
+
+	return list
+}
@@ -0,0 +1,26 @@
+package vote
+
+import (
+	"io"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Telegram - part of telegram bot api used by Bot, implemented by *tb.Bot.
+type Telegram interface {
+	Handle(endpoint interface{}, handler interface{})
+	Start()
+
+	Send(to tb.Recipient, what interface{}, options ...interface{}) (*tb.Message, error)
+	Edit(message tb.Editable, what interface{}, options ...interface{}) (*tb.Message, error)
+	Respond(c *tb.Callback, resp ...*tb.CallbackResponse) error
+	Answer(query *tb.Query, resp *tb.QueryResponse) error
+	Pin(message tb.Editable, options ...interface{}) error
+	Unpin(chat *tb.Chat) error
+	ChatByID(id string) (*tb.Chat, error)
+	AdminsOf(chat *tb.Chat) ([]tb.ChatMember, error)
+	GetFile(file *tb.File) (io.ReadCloser, error)
+	Raw(method string, payload interface{}) ([]byte, error)
+}
+
+var _ Telegram = (*tb.Bot)(nil)
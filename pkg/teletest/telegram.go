@@ -0,0 +1,435 @@
+// Package teletest provides a fake telegram bot api to drive vote.Bot without network.
+package teletest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+var cmdRx = regexp.MustCompile(`^(/\w+)(@(\w+))?(\s|$)(.+)?`)
+
+// Message - message recorded by the fake with its keyboard and parse mode.
+type Message struct {
+	*tb.Message
+
+	Markup    *tb.ReplyMarkup
+	ParseMode tb.ParseMode
+	Payload   interface{}
+}
+
+// Telegram - fake telegram api, records sent and edited messages and simulates updates.
+type Telegram struct {
+	mu sync.Mutex
+
+	Me *tb.User
+
+	lastID   int
+	handlers map[string]interface{}
+	chats    map[int64]*tb.Chat
+	messages map[string]*Message
+	pinned   map[int64]*Message
+	admins   map[int64][]tb.ChatMember
+	files    map[string][]byte
+
+	Sent      []Message
+	Edited    []Message
+	Responses []tb.CallbackResponse
+	Answers   []tb.QueryResponse
+}
+
+// NewTelegram - return new fake telegram api for bot `me`.
+func NewTelegram(me *tb.User) *Telegram {
+	return &Telegram{
+		Me:       me,
+		handlers: map[string]interface{}{},
+		chats:    map[int64]*tb.Chat{},
+		messages: map[string]*Message{},
+		pinned:   map[int64]*Message{},
+		admins:   map[int64][]tb.ChatMember{},
+		files:    map[string][]byte{},
+	}
+}
+
+// AddChat - register chat to be found by ChatByID and used as messages destination.
+func (t *Telegram) AddChat(chat *tb.Chat) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.chats[chat.ID] = chat
+}
+
+// Handle - register handler the same way as tb.Bot does.
+func (t *Telegram) Handle(endpoint interface{}, handler interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch end := endpoint.(type) {
+	case string:
+		t.handlers[end] = handler
+	case tb.CallbackEndpoint:
+		t.handlers[end.CallbackUnique()] = handler
+	default:
+		panic("teletest: unsupported endpoint")
+	}
+}
+
+// Start - no-op, updates are simulated with Dispatch and Press.
+func (t *Telegram) Start() {}
+
+// Send - record message sent to recipient.
+func (t *Telegram) Send(to tb.Recipient, what interface{}, options ...interface{}) (*tb.Message, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	chatID, err := strconv.ParseInt(to.Recipient(), 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "wrong recipient %q", to.Recipient())
+	}
+
+	t.lastID++
+	m := &Message{
+		Message: &tb.Message{
+			ID:       t.lastID,
+			Sender:   t.Me,
+			Unixtime: time.Now().Unix(),
+			Chat:     t.chat(chatID),
+		},
+	}
+
+	switch w := what.(type) {
+	case string:
+		m.Text = w
+	case *tb.Location:
+		m.Location = w
+	case *tb.Document:
+		m.Document = w
+	default:
+		m.Payload = what
+	}
+	m.Markup, m.ParseMode = extractOptions(options)
+
+	t.messages[key(m.Message)] = m
+	t.Sent = append(t.Sent, *m)
+
+	return m.Message, nil
+}
+
+// Edit - replace text and keyboard of the sent message.
+func (t *Telegram) Edit(message tb.Editable, what interface{}, options ...interface{}) (*tb.Message, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	msgID, chatID := message.MessageSig()
+	m, ok := t.messages[msgID+"/"+strconv.FormatInt(chatID, 10)]
+	if !ok {
+		return nil, errors.Errorf("telegram: message to edit not found (%s in %d)", msgID, chatID)
+	}
+
+	text, _ := what.(string)
+	markup, parseMode := extractOptions(options)
+	if text == m.Text && fmt.Sprint(markup) == fmt.Sprint(m.Markup) {
+		return nil, errors.New("telegram: Bad Request: message is not modified")
+	}
+
+	m.Text, m.Markup, m.ParseMode = text, markup, parseMode
+	t.Edited = append(t.Edited, *m)
+
+	return m.Message, nil
+}
+
+// Respond - record callback response.
+func (t *Telegram) Respond(c *tb.Callback, resp ...*tb.CallbackResponse) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r := tb.CallbackResponse{CallbackID: c.ID}
+	if len(resp) > 0 && resp[0] != nil {
+		r = *resp[0]
+		r.CallbackID = c.ID
+	}
+	t.Responses = append(t.Responses, r)
+
+	return nil
+}
+
+// Answer - record inline query answer.
+func (t *Telegram) Answer(q *tb.Query, resp *tb.QueryResponse) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	resp.QueryID = q.ID
+	t.Answers = append(t.Answers, *resp)
+
+	return nil
+}
+
+// Pin - pin sent message in its chat.
+func (t *Telegram) Pin(message tb.Editable, options ...interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	msgID, chatID := message.MessageSig()
+	m, ok := t.messages[msgID+"/"+strconv.FormatInt(chatID, 10)]
+	if !ok {
+		return errors.Errorf("telegram: message to pin not found (%s in %d)", msgID, chatID)
+	}
+	t.pinned[chatID] = m
+
+	return nil
+}
+
+// Unpin - unpin message in chat.
+func (t *Telegram) Unpin(chat *tb.Chat) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.pinned, chat.ID)
+
+	return nil
+}
+
+// ChatByID - return chat registered by AddChat.
+func (t *Telegram) ChatByID(id string) (*tb.Chat, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	chatID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "wrong chat id %q", id)
+	}
+
+	chat, ok := t.chats[chatID]
+	if !ok {
+		return nil, errors.New("telegram: Bad Request: chat not found")
+	}
+
+	return chat, nil
+}
+
+// Raw - answer raw api calls, only `getChat` is supported.
+func (t *Telegram) Raw(method string, payload interface{}) ([]byte, error) {
+	if method != "getChat" {
+		return nil, errors.Errorf("teletest: unsupported method %s", method)
+	}
+
+	params, _ := payload.(map[string]string)
+	chat, err := t.ChatByID(params["chat_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := map[string]interface{}{"id": chat.ID, "type": chat.Type}
+	if m, ok := t.pinned[chat.ID]; ok {
+		result["pinned_message"] = m.Message
+	}
+
+	return json.Marshal(map[string]interface{}{"ok": true, "result": result})
+}
+
+// Pinned - return message pinned in chat, nil if there is none.
+func (t *Telegram) Pinned(chatID int64) *Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.pinned[chatID]
+}
+
+// Message - return current state of the sent message.
+func (t *Telegram) Message(m *tb.Message) *Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.messages[key(m)]
+}
+
+// Dispatch - simulate incoming message, commands go to their handlers,
+// documents to tb.OnDocument, other text to tb.OnText. Return false if nobody handled it.
+func (t *Telegram) Dispatch(m *tb.Message) bool {
+	if match := cmdRx.FindStringSubmatch(m.Text); match != nil {
+		if match[3] == "" || match[3] == t.Me.Username {
+			m.Payload = match[5]
+			if t.call(match[1], m) {
+				return true
+			}
+		}
+	}
+
+	if m.Document != nil {
+		return t.call(tb.OnDocument, m)
+	}
+
+	if m.Text != "" {
+		return t.call(tb.OnText, m)
+	}
+
+	return false
+}
+
+// Press - simulate press of inline button `unique` of message `m` by user `from`.
+func (t *Telegram) Press(m *tb.Message, unique string, from *tb.User) error {
+	btn, err := t.button(m, unique)
+	if err != nil {
+		return err
+	}
+
+	return t.PressData(m, unique, btn.Data, from)
+}
+
+// PressData - simulate press of inline button `unique` of message `m` by user `from`
+// with callback `data`, which may differ from the button data like in forged callbacks.
+func (t *Telegram) PressData(m *tb.Message, unique, data string, from *tb.User) error {
+	if _, err := t.button(m, unique); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.lastID++
+	c := &tb.Callback{ID: strconv.Itoa(t.lastID), Sender: from, Message: t.messages[key(m)].Message, Data: data}
+	t.mu.Unlock()
+
+	if !t.call("\f"+unique, c) {
+		return errors.Errorf("teletest: no handler for button %s", unique)
+	}
+
+	return nil
+}
+
+// button - the first inline button `unique` of message `m`.
+func (t *Telegram) button(m *tb.Message, unique string) (tb.InlineButton, error) {
+	sent := t.Message(m)
+	if sent == nil || sent.Markup == nil {
+		return tb.InlineButton{}, errors.Errorf("teletest: message %d has no keyboard", m.ID)
+	}
+
+	for _, row := range sent.Markup.InlineKeyboard {
+		for _, btn := range row {
+			if btn.Unique == unique {
+				return btn, nil
+			}
+		}
+	}
+
+	return tb.InlineButton{}, errors.Errorf("teletest: message %d has no button %s", m.ID, unique)
+}
+
+// Query - simulate inline query, return false if nobody handled it.
+func (t *Telegram) Query(q *tb.Query) bool {
+	return t.call(tb.OnQuery, q)
+}
+
+func (t *Telegram) call(endpoint string, arg interface{}) bool {
+	t.mu.Lock()
+	handler, ok := t.handlers[endpoint]
+	t.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	switch h := handler.(type) {
+	case func(*tb.Message):
+		m, ok := arg.(*tb.Message)
+		if ok {
+			h(m)
+		}
+		return ok
+	case func(*tb.Callback):
+		c, ok := arg.(*tb.Callback)
+		if ok {
+			h(c)
+		}
+		return ok
+	case func(*tb.Query):
+		q, ok := arg.(*tb.Query)
+		if ok {
+			h(q)
+		}
+		return ok
+	}
+
+	return false
+}
+
+func (t *Telegram) chat(id int64) *tb.Chat {
+	if chat, ok := t.chats[id]; ok {
+		return chat
+	}
+
+	chat := &tb.Chat{ID: id, Type: tb.ChatPrivate}
+	t.chats[id] = chat
+
+	return chat
+}
+
+func key(m *tb.Message) string {
+	msgID, chatID := m.MessageSig()
+	return msgID + "/" + strconv.FormatInt(chatID, 10)
+}
+
+func extractOptions(options []interface{}) (*tb.ReplyMarkup, tb.ParseMode) {
+	var markup *tb.ReplyMarkup
+	var parseMode tb.ParseMode
+
+	for _, opt := range options {
+		switch o := opt.(type) {
+		case *tb.ReplyMarkup:
+			markup = o
+		case tb.ParseMode:
+			parseMode = o
+		case *tb.SendOptions:
+			markup, parseMode = o.ReplyMarkup, o.ParseMode
+		}
+	}
+
+	return markup, parseMode
+}
+
+// SetAdmins - set administrators of chat returned by AdminsOf.
+func (t *Telegram) SetAdmins(chatID int64, members []tb.ChatMember) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.admins[chatID] = members
+}
+
+// AdminsOf - return administrators of chat set by SetAdmins.
+func (t *Telegram) AdminsOf(chat *tb.Chat) ([]tb.ChatMember, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.admins[chat.ID], nil
+}
+
+// AddFile - store file `content` to be downloaded by GetFile with `fileID`.
+func (t *Telegram) AddFile(fileID string, content []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.files[fileID] = content
+}
+
+// GetFile - return content of the file stored by AddFile.
+func (t *Telegram) GetFile(file *tb.File) (io.ReadCloser, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	content, ok := t.files[file.FileID]
+	if !ok {
+		return nil, errors.Errorf("teletest: file %s is not found", file.FileID)
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
@@ -0,0 +1,145 @@
+package vote
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const openMeteoURL = "https://api.open-meteo.com/v1/forecast"
+
+// weatherTTL - how long forecasts are cached.
+const weatherTTL = time.Hour
+
+// Weather - forecast for the game time.
+type Weather struct {
+	// Temperature - air temperature, °C.
+	Temperature float64
+	// Precipitation - probability of precipitation, %.
+	Precipitation int
+	// Summary - emoji of the weather conditions.
+	Summary string
+}
+
+// WeatherProvider - source of weather forecasts.
+type WeatherProvider interface {
+	// Forecast - return forecast at place and time, nil if it is unknown.
+	Forecast(lat, lng float64, at time.Time) *Weather
+}
+
+// OpenMeteo - WeatherProvider backed by open-meteo.com, doesn't require an api key.
+type OpenMeteo struct {
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedWeather
+}
+
+type cachedWeather struct {
+	weather *Weather
+	at      time.Time
+}
+
+// NewOpenMeteo - return new open-meteo.com client.
+func NewOpenMeteo() *OpenMeteo {
+	return &OpenMeteo{
+		client: &http.Client{Timeout: 5 * time.Second},
+		cache:  map[string]cachedWeather{},
+	}
+}
+
+// Forecast - return cached or freshly fetched forecast for the hour of `at`.
+func (o *OpenMeteo) Forecast(lat, lng float64, at time.Time) *Weather {
+	hour := at.UTC().Format("2006-01-02T15:00")
+	key := fmt.Sprintf("%.3f,%.3f,%s", lat, lng, hour)
+
+	o.mu.Lock()
+	cached, ok := o.cache[key]
+	o.mu.Unlock()
+	if ok && time.Since(cached.at) < weatherTTL {
+		return cached.weather
+	}
+
+	w, err := o.fetch(lat, lng, hour)
+	if err != nil {
+		log.Printf("cannot get weather: %s", err)
+	}
+
+	o.mu.Lock()
+	o.cache[key] = cachedWeather{weather: w, at: time.Now()}
+	o.mu.Unlock()
+
+	return w
+}
+
+func (o *OpenMeteo) fetch(lat, lng float64, hour string) (*Weather, error) {
+	day := hour[:len("2006-01-02")]
+	url := fmt.Sprintf(
+		"%s?latitude=%f&longitude=%f&hourly=temperature_2m,precipitation_probability,weathercode&timezone=GMT&start_date=%s&end_date=%s",
+		openMeteoURL, lat, lng, day, day,
+	)
+
+	resp, err := o.client.Get(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot request forecast")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("forecast request failed: %s", resp.Status)
+	}
+
+	var forecast struct {
+		Hourly struct {
+			Time          []string  `json:"time"`
+			Temperature   []float64 `json:"temperature_2m"`
+			Precipitation []int     `json:"precipitation_probability"`
+			Code          []int     `json:"weathercode"`
+		} `json:"hourly"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&forecast); err != nil {
+		return nil, errors.Wrap(err, "cannot decode forecast")
+	}
+
+	h := forecast.Hourly
+	for i, t := range h.Time {
+		if t != hour || i >= len(h.Temperature) || i >= len(h.Precipitation) || i >= len(h.Code) {
+			continue
+		}
+
+		return &Weather{
+			Temperature:   h.Temperature[i],
+			Precipitation: h.Precipitation[i],
+			Summary:       weatherSymbol(h.Code[i]),
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// weatherSymbol - emoji of WMO weather code.
+func weatherSymbol(code int) string {
+	switch {
+	case code == 0:
+		return "☀️"
+	case code <= 3:
+		return "⛅️"
+	case code == 45 || code == 48:
+		return "🌫"
+	case code >= 51 && code <= 67:
+		return "🌧"
+	case code >= 71 && code <= 77:
+		return "🌨"
+	case code >= 80 && code <= 86:
+		return "🌦"
+	case code >= 95:
+		return "⛈"
+	}
+
+	return ""
+}